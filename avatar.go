@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// avatarCacheTTL is how long the result of checking an avatar is used,
+	// avatarRetryTTL how long to wait before checking again after a failure.
+	avatarCacheTTL     = 6 * time.Hour
+	avatarRetryTTL     = 10 * time.Minute
+	avatarCheckTimeout = 3 * time.Second
+)
+
+// rockstarIDPattern matches the Social Club IDs used in avatar URLs.
+var rockstarIDPattern = regexp.MustCompile(`^[0-9]{9}$`)
+
+// avatars builds the URLs of Social Club avatars and, if checking is enabled,
+// falls back to the unknown avatar for those that do not exist. Avatars are
+// checked in the background, so building URLs never waits for the network.
+type avatars struct {
+	baseURL string
+	check   bool
+	client  *http.Client
+
+	mu       sync.Mutex
+	found    map[string]avatarCheck  // results by R* ID
+	checking map[string][]func(bool) // callbacks of running checks by R* ID
+}
+
+type avatarCheck struct {
+	found   bool
+	expires time.Time
+}
+
+func newAvatars(baseURL string, check bool) *avatars {
+	return &avatars{
+		baseURL:  baseURL,
+		check:    check,
+		client:   &http.Client{Timeout: avatarCheckTimeout},
+		found:    make(map[string]avatarCheck),
+		checking: make(map[string][]func(bool)),
+	}
+}
+
+// url returns the avatar of the R* ID or the unknown avatar if the ID is not
+// set, invalid or, with checking enabled, known to have no avatar.
+func (a *avatars) url(rockstarID string) string {
+	if !rockstarIDPattern.MatchString(rockstarID) {
+		return rdoAvatarUnknownURL
+	}
+	if a.check && !a.exists(rockstarID, nil) {
+		return rdoAvatarUnknownURL
+	}
+	return a.baseURL + rockstarID + rdoAvatarURLSuffix
+}
+
+// exists returns the cached result for the R* ID. If there is none or it
+// expired, the avatar is checked in the background and assumed to exist
+// until then. done, if not nil, is called with the result once known.
+func (a *avatars) exists(rockstarID string, done func(found bool)) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, ok := a.found[rockstarID]
+	if ok && time.Now().Before(c.expires) {
+		if done != nil {
+			go done(c.found)
+		}
+		return c.found
+	}
+
+	callbacks, running := a.checking[rockstarID]
+	if done != nil {
+		callbacks = append(callbacks, done)
+	}
+	a.checking[rockstarID] = callbacks
+	if !running {
+		go a.fetch(rockstarID)
+	}
+	return !ok || c.found
+}
+
+// fetch checks the avatar and caches the result. Only a 404 counts as
+// missing, so the avatars stay visible if the check fails otherwise.
+func (a *avatars) fetch(rockstarID string) {
+	c := avatarCheck{found: true, expires: time.Now().Add(avatarRetryTTL)}
+	ctx, cancel := context.WithTimeout(context.Background(), avatarCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, a.baseURL+rockstarID+rdoAvatarURLSuffix, nil)
+	var res *http.Response
+	if err == nil {
+		res, err = a.client.Do(req)
+	}
+	if err != nil {
+		slog.Warn("Could not check avatar", "rockstar_id", rockstarID, "err", err)
+	} else {
+		res.Body.Close()
+		c = avatarCheck{found: res.StatusCode != http.StatusNotFound, expires: time.Now().Add(avatarCacheTTL)}
+	}
+
+	a.mu.Lock()
+	a.found[rockstarID] = c
+	callbacks := a.checking[rockstarID]
+	delete(a.checking, rockstarID)
+	a.mu.Unlock()
+	for _, done := range callbacks {
+		done(c.found)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAvatarURL(t *testing.T) {
+	t.Parallel()
+	var checks atomic.Int32
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		if r.Method != http.MethodHead {
+			t.Errorf("avatar checked with %s, want HEAD", r.Method)
+		}
+		if !strings.HasPrefix(r.URL.Path, "/pedshot/123456789/") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer stub.Close()
+
+	a := newAvatars(stub.URL+"/pedshot/", true)
+	exists := func(a *avatars, id string) bool {
+		t.Helper()
+		found := make(chan bool)
+		a.exists(id, func(ok bool) { found <- ok })
+		select {
+		case ok := <-found:
+			return ok
+		case <-time.After(5 * time.Second):
+			t.Fatalf("avatar of %s was not checked", id)
+			return false
+		}
+	}
+
+	// Until checked, valid IDs use their avatar
+	if got, want := a.url("987654321"), stub.URL+"/pedshot/987654321"+rdoAvatarURLSuffix; got != want {
+		t.Errorf("unchecked url = %q, want %q", got, want)
+	}
+	if !exists(a, "123456789") || exists(a, "987654321") {
+		t.Error("wrong results for existing and missing avatar")
+	}
+	for _, tt := range []struct{ id, want string }{
+		{"", rdoAvatarUnknownURL},
+		{"12345", rdoAvatarUnknownURL},
+		{"12345678a", rdoAvatarUnknownURL},
+		{"123456789", stub.URL + "/pedshot/123456789" + rdoAvatarURLSuffix},
+		{"987654321", rdoAvatarUnknownURL},
+	} {
+		if got := a.url(tt.id); got != tt.want {
+			t.Errorf("url(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+	if n := checks.Load(); n != 2 {
+		t.Errorf("checked %d avatars, want each valid ID once", n)
+	}
+
+	// Failed checks are cached for a shorter time and keep the avatar
+	down := newAvatars("http://127.0.0.1:0/", true)
+	down.client.Timeout = time.Second
+	if !exists(down, "123456789") {
+		t.Error("avatar missing after failed check")
+	}
+	c := down.found["123456789"]
+	if time.Until(c.expires) > avatarRetryTTL {
+		t.Errorf("failed check cached until %v, want at most %v", c.expires, avatarRetryTTL)
+	}
+
+	// Without checking the URL is used as is
+	if got := newAvatars(stub.URL+"/", false).url("987654321"); got != stub.URL+"/987654321"+rdoAvatarURLSuffix {
+		t.Errorf("unchecked url = %q", got)
+	}
+	if n := checks.Load(); n != 2 {
+		t.Errorf("checked %d times with checking disabled", n)
+	}
+}
+
+func TestRockstarIDValidation(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+	stub := httptest.NewServer(http.NotFoundHandler())
+	defer stub.Close()
+	b.Avatars = newAvatars(stub.URL+"/", true)
+
+	retry := func(res *fakeResponse) string {
+		t.Helper()
+		if len(res.Message.Components) == 0 {
+			t.Fatalf("response %q has no retry button", res.Message.Content)
+		}
+		return res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+	}
+
+	res := f.submit(testUser, general, "setup:"+testUser.ID, map[string]string{"rockstar_id": "12345678x", "bounty": "", "footer": ""})
+	if !strings.Contains(res.Message.Content, "9 digit") || retry(res) != "setup" {
+		t.Errorf("setup with invalid R* ID = %q", res.Message.Content)
+	}
+	if _, err := b.Players.Player(context.Background(), b.guild(testGuildID).player(testUser.ID)); err == nil {
+		t.Errorf("profile created despite invalid R* ID")
+	}
+
+	f.submit(testUser, general, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	res = f.submit(testUser, general, "set_rid:"+testUser.ID, map[string]string{"rockstar_id": "1234"})
+	if !strings.Contains(res.Message.Content, "9 digit") || retry(res) != "set_rid" {
+		t.Errorf("set_rid with invalid R* ID = %q", res.Message.Content)
+	}
+
+	// The stub has no avatars, so the ID is saved with a hint to check it
+	res = f.submit(testUser, general, "set_rid:"+testUser.ID, map[string]string{"rockstar_id": " 123456789 "})
+	if !strings.Contains(res.Message.Content, "Successfully") {
+		t.Errorf("set_rid without avatar = %q", res.Message.Content)
+	}
+	if followups := f.waitFollowups(res, 1); !strings.Contains(followups[0].Content, "No Social Club avatar") {
+		t.Errorf("followup = %q", followups[0].Content)
+	}
+	p, err := b.Players.Player(context.Background(), b.guild(testGuildID).player(testUser.ID))
+	if err != nil || p.RockstarId != "123456789" {
+		t.Fatalf("player = %+v, %v, want the trimmed R* ID", p, err)
+	}
+	if got := b.onlineEmbed(p, p.Platform).Thumbnail.URL; got != rdoAvatarUnknownURL {
+		t.Errorf("avatar = %q, want the unknown avatar", got)
+	}
+}
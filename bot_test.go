@@ -0,0 +1,357 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var testUser = &discordgo.User{ID: "300", Username: "arthur"}
+
+func TestGuildSetup(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+
+	var names []string
+	for _, c := range f.registeredCommands() {
+		names = append(names, c.Name)
+	}
+	if want := []string{"setup", "me", "profile", "online", "offline", "show", "stats", "leaderboard", "auto-online", "event", "watch", "posse", "lfg"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("registered commands = %v, want %v", names, want)
+	}
+
+	instructions := f.channelMessages(f.channelID(b.Config.Channels.Commands))
+	if len(instructions) < 2 {
+		t.Fatalf("got %d messages in commands channel, want the instructions split over several", len(instructions))
+	}
+	g := b.guild(testGuildID)
+	if mention := g.commandMention("setup"); !strings.Contains(instructions[0].Content, mention) {
+		t.Errorf("command instructions do not mention %s:\n%s", mention, instructions[0].Content)
+	}
+
+	rolesChannel := f.channelID(b.Config.Channels.Roles)
+	roleMessages := f.channelMessages(rolesChannel)
+	if len(roleMessages) != 1 {
+		t.Fatalf("got %d messages in roles channel, want 1", len(roleMessages))
+	}
+	if g.RolesMessageID != roleMessages[0].ID {
+		t.Errorf("stored roles message = %q, want %q", g.RolesMessageID, roleMessages[0].ID)
+	}
+	if got := roleMessages[0].Embeds[0].Description; got != b.Config.roleDescription() {
+		t.Errorf("roles message = %q, want %q", got, b.Config.roleDescription())
+	}
+
+	reactions := f.messageReactions(roleMessages[0].ID)
+	if len(reactions) != len(b.Config.Roles) {
+		t.Errorf("bot reacted with %v, want one reaction per role", reactions)
+	}
+	if got := f.rolesOf(testBotID); len(got) != len(b.Config.Roles) {
+		t.Errorf("bot has roles %v, want one per configured role", got)
+	}
+}
+
+func TestRoleSelfAssignment(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	rolesChannel := f.channelID(b.Config.Channels.Roles)
+	message := b.guild(testGuildID).RolesMessageID
+	role := b.Config.Roles[0]
+
+	f.react(testUser.ID, rolesChannel, message, role.Emoji)
+	if got, want := f.rolesOf(testUser.ID), []string{f.roleID(role.Name)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("roles after reacting = %v, want %v", got, want)
+	}
+
+	f.react(testUser.ID, rolesChannel, "other", b.Config.Roles[1].Emoji)
+	if got := f.rolesOf(testUser.ID); len(got) != 1 {
+		t.Errorf("reacting to another message changed roles to %v", got)
+	}
+
+	f.unreact(testUser.ID, rolesChannel, message, role.Emoji)
+	if got := f.rolesOf(testUser.ID); len(got) != 0 {
+		t.Errorf("roles after removing reaction = %v, want none", got)
+	}
+}
+
+func TestWelcome(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+
+	f.join(testUser)
+	messages := f.channelMessages(general)
+	if len(messages) != 1 {
+		t.Fatalf("got %d welcome messages, want 1", len(messages))
+	}
+	if !strings.Contains(messages[0].Content, "<@"+testUser.ID+">") {
+		t.Errorf("welcome message does not mention the user: %q", messages[0].Content)
+	}
+
+	// Members that already have roles are returning and not welcomed again
+	f.join(&discordgo.User{ID: "301", Username: "john"}, f.roleID(b.Config.Roles[0].Name))
+	if got := len(f.channelMessages(general)); got != 1 {
+		t.Errorf("got %d welcome messages after returning member joined, want 1", got)
+	}
+}
+
+func TestSetupOnlineShowOffline(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	platform := b.Config.Platforms[0]
+	channel := f.channelID(platform.Channel)
+
+	res := f.command(testUser, channel, "online")
+	if !strings.Contains(res.Message.Content, "You have not set up your profile") {
+		t.Errorf("/online without profile = %q, want setup hint", res.Message.Content)
+	}
+
+	res = f.command(testUser, channel, "setup")
+	if res.Type != discordgo.InteractionResponseModal || res.CustomID != "setup:"+testUser.ID+":"+platform.Name {
+		t.Fatalf("/setup responded with type %d and custom ID %q, want setup modal", res.Type, res.CustomID)
+	}
+	res = f.submit(testUser, channel, res.CustomID, map[string]string{"rockstar_id": "123456789", "bounty": "12.5", "footer": "Hunting"})
+	if !strings.Contains(res.Message.Content, "initial profile info is now set") {
+		t.Errorf("setup modal response = %q", res.Message.Content)
+	}
+
+	res = f.command(testUser, f.channelID(b.Config.Channels.General), "online")
+	if !strings.Contains(res.Message.Content, "only in") {
+		t.Errorf("/online outside platform channel = %q, want channel hint", res.Message.Content)
+	}
+
+	res = f.command(testUser, channel, "online")
+	if len(res.Message.Embeds) != 1 || res.Message.Embeds[0].Title != testUser.Username+" is now online." {
+		t.Fatalf("/online responded with %+v", res.Message)
+	}
+	if got := res.Message.Embeds[0].Thumbnail.URL; got != rdoAvatarURLPrefix+"123456789"+rdoAvatarURLSuffix {
+		t.Errorf("online avatar = %q", got)
+	}
+	if len(res.Followups) != 1 || len(res.Followups[0].Components) == 0 {
+		t.Errorf("/online sent followups %+v, want quick controls", res.Followups)
+	}
+
+	res = f.command(testUser, channel, "show")
+	if len(res.Message.Embeds) != 1 || res.Message.Embeds[0].Title != testUser.Username {
+		t.Fatalf("/show responded with %+v, want the online player", res.Message.Embeds)
+	}
+	if got := res.Message.Embeds[0].Fields[0].Value; got != "$12.50" {
+		t.Errorf("shown bounty = %q, want $12.50", got)
+	}
+	if got := res.Message.Embeds[0].Footer.Text; got != "Hunting" {
+		t.Errorf("shown footer = %q, want Hunting", got)
+	}
+
+	f.click(testUser, channel, "set_camp")
+	res = f.click(testUser, channel, "camp_selection", b.Config.Camps[0])
+	if !strings.Contains(res.Message.Content, b.Config.Camps[0]) {
+		t.Errorf("camp selection response = %q", res.Message.Content)
+	}
+
+	res = f.command(testUser, channel, "offline")
+	if len(res.Message.Embeds) != 1 || res.Message.Embeds[0].Color != colorRed {
+		t.Errorf("/offline responded with %+v", res.Message.Embeds)
+	}
+
+	res = f.command(testUser, channel, "show")
+	if len(res.Message.Embeds) != 1 || res.Message.Embeds[0].Description != "There are no players online at the moment." {
+		t.Errorf("/show after going offline responded with %+v", res.Message.Embeds)
+	}
+}
+
+func TestInvalidBounty(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[0].Channel)
+
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	res := f.submit(testUser, channel, "set_bounty:"+testUser.ID, map[string]string{"bounty": "lots"})
+	if !strings.Contains(res.Message.Content, "as a number") {
+		t.Errorf("invalid bounty response = %q", res.Message.Content)
+	}
+	res = f.submit(testUser, channel, "set_bounty:"+testUser.ID, map[string]string{"bounty": "-5"})
+	if !strings.Contains(res.Message.Content, "range from $0.00 to $100.00") {
+		t.Errorf("negative bounty response = %q", res.Message.Content)
+	}
+	res = f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "150", "footer": ""})
+	if !strings.Contains(res.Message.Content, "range from $0.00 to $100.00") {
+		t.Errorf("bounty out of range response = %q", res.Message.Content)
+	}
+
+	// The modal cannot be shown again right away, but from the button of the error
+	retry := res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+	res = f.click(testUser, channel, retry)
+	if res.Type != discordgo.InteractionResponseModal || res.CustomID != "setup:"+testUser.ID+":"+b.Config.Platforms[0].Name {
+		t.Errorf("retry button responded with type %d and custom ID %q, want setup modal", res.Type, res.CustomID)
+	}
+
+	res = f.submit(testUser, channel, "set_bounty:"+testUser.ID, map[string]string{"bounty": "$7,25"})
+	if !strings.Contains(res.Message.Content, "$7.25") {
+		t.Errorf("bounty response = %q, want $7.25", res.Message.Content)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	t.Parallel()
+	got := splitMessage([]string{"aaaa", "bbb", "cc", "ddddddddd"}, 9)
+	if want := []string{"aaaa\n\nbbb", "cc", "ddddddddd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitMessage = %q, want %q", got, want)
+	}
+}
+
+func TestSyncInstructions(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	g := b.guild(testGuildID)
+	channel := f.channelID(b.Config.Channels.Commands)
+	contents := func() []string {
+		var contents []string
+		for _, m := range f.channelMessages(channel) {
+			contents = append(contents, m.Content)
+		}
+		return contents
+	}
+	b.syncInstructions(g, nil)
+	if len(contents()) != 0 {
+		t.Fatalf("messages after removing the instructions = %q", contents())
+	}
+	f.postMessage(channel, &discordgo.Message{Content: "Other message of the bot"})
+
+	b.syncInstructions(g, []string{"</setup:1> one", "two", "three"})
+	if want := []string{"Other message of the bot", "</setup:1> one", "two", "three"}; len(g.CommandsMessageIDs) != 3 || !reflect.DeepEqual(contents(), want) {
+		t.Fatalf("instructions = %q with IDs %v", contents(), g.CommandsMessageIDs)
+	}
+	posted := g.CommandsMessageIDs
+
+	// Fewer instructions remove only the surplus instruction messages
+	b.syncInstructions(g, []string{"</setup:1> one", "changed"})
+	if want := []string{"Other message of the bot", "</setup:1> one", "changed"}; !reflect.DeepEqual(contents(), want) {
+		t.Errorf("instructions after shrinking = %q, want %q", contents(), want)
+	}
+	if !reflect.DeepEqual(g.CommandsMessageIDs, posted[:2]) {
+		t.Errorf("instruction IDs = %v, want the first two of %v", g.CommandsMessageIDs, posted)
+	}
+
+	// Without stored IDs the legacy single message is reused
+	g.CommandsMessageIDs = nil
+	b.syncInstructions(g, []string{"</setup:1> one"})
+	if want := []string{"Other message of the bot", "</setup:1> one", "changed"}; !reflect.DeepEqual(contents(), want) {
+		t.Errorf("instructions after restart = %q, want %q", contents(), want)
+	}
+}
+
+func TestParseBounty(t *testing.T) {
+	for input, want := range map[string]float64{
+		"0":       0,
+		"12":      12,
+		"12.5":    12.5,
+		"$12,50":  12.5,
+		"$100.00": 100,
+		"100":     100,
+		"+5":      5,
+	} {
+		if got, err := parseBounty(input); err != nil || got != want {
+			t.Errorf("parseBounty(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	for input, want := range map[string]error{
+		"abc":    errBountyInvalid,
+		"":       errBountyInvalid,
+		"$-5":    errBountyInvalid,
+		"12.345": errBountyInvalid,
+		"1e2":    errBountyInvalid,
+		"0x1p6":  errBountyInvalid,
+		"inf":    errBountyInvalid,
+		"NaN":    errBountyInvalid,
+		"1 000":  errBountyInvalid,
+		"100.01": errBountyRange,
+		"999":    errBountyRange,
+		"1000":   errBountyRange,
+		"-5":     errBountyRange,
+		"-$0.01": errBountyRange,
+	} {
+		if _, err := parseBounty(input); err != want {
+			t.Errorf("parseBounty(%q) failed with %v, want %v", input, err, want)
+		}
+	}
+}
+
+const testChangelog = `# Change Log
+
+## v1.1.0
+### Added
+- Leaderboards
+### Changed
+- Nothing
+### Fixed
+- Typos
+
+.
+
+## v1.0.0
+### Added
+- Everything
+### Changed
+- Nothing
+### Fixed
+- Nothing
+`
+
+func TestChangelogs(t *testing.T) {
+	t.Parallel()
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		io.WriteString(w, testChangelog)
+	}))
+	defer server.Close()
+	b := &Bot{ChangelogURL: server.URL}
+
+	// Servers set up at the same time share one download
+	var wg sync.WaitGroup
+	results := make([][]string, 3)
+	for n := range results {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			changelogs, err := b.changelogs()
+			if err != nil {
+				t.Error(err)
+			}
+			results[n] = changelogs
+		}(n)
+	}
+	wg.Wait()
+
+	if got := downloads.Load(); got != 1 {
+		t.Errorf("changelog downloaded %d times, want once", got)
+	}
+	want := []string{
+		"**v1.1.0**\n```\nAdded\n\n- Leaderboards\n```" + "```\nChanged\n\n- Nothing\n```" + "```\nFixed\n\n- Typos\n```\n",
+		"**v1.0.0**\n```\nAdded\n\n- Everything\n```" + "```\nChanged\n\n- Nothing\n```" + "```\nFixed\n\n- Nothing\n```\n",
+	}
+	for _, got := range results {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("changelogs = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestChangelogDownloadError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	b := &Bot{ChangelogURL: server.URL}
+	if _, err := b.changelogs(); err == nil {
+		t.Error("downloading a missing changelog succeeded")
+	}
+	if _, err := parseChangelog([]byte("# Change Log\n\nNo releases yet")); err == nil {
+		t.Error("parsing a changelog without releases succeeded")
+	}
+}
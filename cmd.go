@@ -2,31 +2,15 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Player struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Name       string             `bson:"name"`
-	DiscordId  string             `bson:"discord_id"`
-	RockstarId string             `bson:"rockstar_id"`
-	Bounty     string             `bson:"bounty"`
-	Camp       string             `bson:"camp"`
-	Footer     string             `bson:"footer"`
-	Online     bool               `bson:"online"`
-	Platform   string             `bson:"platform"`
-	Time       time.Time          `bson:"time"`
-	Expires    time.Time          `bson:"expires"`
-}
-
 var (
 	commands = []*discordgo.ApplicationCommand{
 		{
@@ -37,6 +21,18 @@ var (
 			Name:        "me",
 			Description: "Show and edit your current profile info.",
 		},
+		{
+			Name:        "profile",
+			Description: "Show the profile of another member.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The member to show.",
+					Required:    true,
+				},
+			},
+		},
 		{
 			Name:        "online",
 			Description: "Flag yourself as online in this channel.",
@@ -49,8 +45,189 @@ var (
 			Name:        "show",
 			Description: "See who is currently online.",
 		},
+		{
+			Name:        "stats",
+			Description: "Show your playtime statistics.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "Time window, the last 30 days by default.",
+					Choices:     periodChoices(),
+				},
+			},
+		},
+		{
+			Name:        "leaderboard",
+			Description: "Show who played the most or had the highest bounty.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "category",
+					Description: "What to rank players by, playtime by default.",
+					Choices:     categoryChoices(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "period",
+					Description: "Time window, the last 30 days by default.",
+					Choices:     periodChoices(),
+				},
+			},
+		},
+		{
+			Name:        "auto-online",
+			Description: "Go online and offline automatically while Discord shows you playing Red Dead.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Turn automatic online detection on or off.",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "on", Value: "on"},
+						{Name: "off", Value: "off"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "event",
+			Description: "Schedule group sessions.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Schedule a group session others can sign up for.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "title",
+							Description: "Name of the event.",
+							Required:    true,
+							MaxLength:   100,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "start",
+							Description: "Start like 2023-05-01 20:00 +02:00, in UTC without an offset.",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "activity",
+							Description: "What you are going to do, e.g. legendary bounties.",
+							MaxLength:   200,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "platform",
+							Description: "Platform to play on, the one of this channel by default.",
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "slots",
+							Description: "Maximum number of players, unlimited by default.",
+							MinValue:    &minEventSlots,
+							MaxValue:    100,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "watch",
+			Description: "Get a DM when players go online.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Get a DM when a player goes online.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "player",
+							Description: "Player to watch.",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop watching a player.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "player",
+							Description: "Player to stop watching.",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Show the players you are watching.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "mute",
+					Description: "Pause your watchlist notifications for a while.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "for",
+							Description: "How long to pause the notifications.",
+							Required:    true,
+							Choices:     muteChoices(),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "posse",
+			Description: "Group up with the players you play with.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Start a posse on the platform you are online on.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "invite",
+					Description: "Invite a player to your posse.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Name:        "player",
+							Description: "Player to invite.",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "leave",
+					Description: "Leave your posse, which disbands it if you lead it.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "disband",
+					Description: "Disband the posse you lead.",
+				},
+			},
+		},
 	}
 
+	minEventSlots = 1.0
+
+	errBountyInvalid = errors.New("bounty is not a number")
+	errBountyRange   = errors.New("bounty is out of range")
+	errRankInvalid   = errors.New("rank is not a number in range")
+
 	onlineControlButtons = []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
@@ -82,966 +259,528 @@ var (
 			},
 		},
 	}
-	profileControlButtons = []discordgo.MessageComponent{
-		discordgo.ActionsRow{
-			Components: []discordgo.MessageComponent{
-				discordgo.Button{
-					Label:    "Set Bounty",
-					Style:    discordgo.SecondaryButton,
-					CustomID: "set_bounty",
-				},
-				discordgo.Button{
-					Label:    "Set Camp",
-					Style:    discordgo.SecondaryButton,
-					CustomID: "set_camp",
-				},
-				discordgo.Button{
-					Label:    "Set Footer",
-					Style:    discordgo.SecondaryButton,
-					CustomID: "set_footer",
-				},
-				discordgo.Button{
-					Label:    "Set R* ID",
-					Style:    discordgo.SecondaryButton,
-					CustomID: "set_rid",
-				},
-			},
-		},
-	}
 
-	commandHandlers = map[string]func(b *Bot, i *discordgo.InteractionCreate){
-		"setup": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used /setup in channel " + i.ChannelID)
-			err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseModal,
-				Data: &discordgo.InteractionResponseData{
-					Title:   "Profile Setup",
-					Content: "Enter your current data to get you started. \nYou only have to do this once or after the bot was offline.",
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "rid_input_" + i.Member.User.ID,
-									Label:       "R* ID:",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "123456789",
-									Required:    false,
-									MinLength:   9,
-									MaxLength:   9,
-								},
-							},
-						},
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "bounty_input_" + i.Member.User.ID,
-									Label:       "Bounty (0-100):",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "19.99",
-									Required:    false,
-									MinLength:   1,
-									MaxLength:   5,
-								},
-							},
-						},
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "foot_input_" + i.Member.User.ID,
-									Label:       "Footer Message:",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "What are you up to?",
-									Required:    false,
-									MaxLength:   42,
-								},
-							},
-						},
-					},
-					Flags:    discordgo.MessageFlagsEphemeral,
-					CustomID: "setup_" + i.Member.User.ID,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+	commandHandlers = map[string]handlerFunc{
+		"setup": func(b *Bot, g *guild, i *interaction) error {
+			return b.openSetup(g, i)
 		},
-		"me": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used /me in channel " + i.ChannelID)
-			var result Player
-			avatarURL := rdoAvatarUnknownURL
-			rockstarIdStatus := "R* ID is not set"
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
-
-			err := b.Collection.FindOne(context.TODO(), filter).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Content: "You have not set up your profile. \nPlease use </setup:" + b.setupCommandID + "> to start. 🤠",
-							Flags:   discordgo.MessageFlagsEphemeral,
-						},
-					})
-					if err != nil {
-						b.ErrorReport.Notify(err, nil)
-						log.Println(err)
-					}
-				}
-			}
-			if result.RockstarId != "" {
-				rockstarIdStatus = "R* ID is set"
-				avatarURL = strings.Join([]string{rdoAvatarURLPrefix, result.RockstarId, rdoAvatarURLSuffix}, "")
-			}
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Embeds: []*discordgo.MessageEmbed{
-						{
-							Type:        discordgo.EmbedTypeRich,
-							Title:       "Your current profile data:",
-							Description: rockstarIdStatus + "\n Camp: " + result.Camp + "\n Bounty: $" + result.Bounty + "\n Footer: " + result.Footer,
-							Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: avatarURL},
-						},
-					},
-					Flags: discordgo.MessageFlagsEphemeral,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-
-			_, err = b.Session.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: "Update your profile data below:\n\nTo find your R* ID, visit your Social Club profile here: <https://socialclub.rockstargames.com/games/rdr2/overview>.\nOn the tiny avatar of your character do a right-click and click on *Open image in new tab*. In the browser address bar you will notice a 9-digit number (just before */pedshot_0.jpg*) which is your Rockstar ID.\n", Components: profileControlButtons, Flags: discordgo.MessageFlagsEphemeral})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+		"me": func(b *Bot, g *guild, i *interaction) error {
+			return b.showProfile(g, i)
 		},
-		"online": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used /online in channel " + i.ChannelID)
-			if i.ChannelID == b.pcChannelID || i.ChannelID == b.playstationChannelID || i.ChannelID == b.xboxChannelID {
-				var result Player
-				avatarURL := rdoAvatarUnknownURL
-				platform := "-"
-				filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
-
-				err := b.Collection.FindOne(context.TODO(), filter).Decode(&result)
+		"online": func(b *Bot, g *guild, i *interaction) error {
+			if platform, ok := g.platform(i.ChannelID); ok {
+				result, err := b.setOnline(context.TODO(), g.player(i.Member.User.ID), platform, i.log)
 				if err != nil {
-					if err == mongo.ErrNoDocuments {
-						err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-							Type: discordgo.InteractionResponseChannelMessageWithSource,
-							Data: &discordgo.InteractionResponseData{
-								Content: "You have not set up your profile. \nPlease use </setup:" + b.setupCommandID + "> to start. 🤠",
-								Flags:   discordgo.MessageFlagsEphemeral,
-							},
-						})
-						if err != nil {
-							b.ErrorReport.Notify(err, nil)
-							log.Println(err)
-						}
+					if err == ErrPlayerNotFound {
+						b.respondSetupRequired(g, i)
+					} else {
+						b.reportError(i.log, "Error setting player online", err)
 					}
+					return nil
 				}
-
-				switch i.ChannelID {
-				case b.pcChannelID:
-					platform = "PC"
-				case b.playstationChannelID:
-					platform = "PS4"
-				case b.xboxChannelID:
-					platform = "XBOX"
-				}
-
-				playerOnline := bson.M{
-					"$set": bson.D{
-						{Key: "online", Value: true},
-						{Key: "platform", Value: platform},
-						{Key: "time", Value: time.Now().Format(time.RFC3339)},
-						{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-					},
-				}
-
-				err = b.Collection.FindOneAndUpdate(context.TODO(), filter, playerOnline).Decode(&result)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-
-				onlineData := []*discordgo.MessageEmbedField{
-					{
-						Name:   "Bounty:",
-						Value:  "$" + result.Bounty,
-						Inline: true,
-					},
-					{
-						Name:   "Camp:",
-						Value:  result.Camp,
-						Inline: true,
-					},
-					{
-						Name:   "Platform:",
-						Value:  platform,
-						Inline: true,
-					},
-				}
-
-				if result.RockstarId != "" {
-					avatarURL = strings.Join([]string{rdoAvatarURLPrefix, result.RockstarId, rdoAvatarURLSuffix}, "")
-				}
+				b.refreshBoards(g.Namespace)
 
 				err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Embeds: []*discordgo.MessageEmbed{
-							{
-								Type:      discordgo.EmbedTypeRich,
-								Color:     colorGreen,
-								Title:     result.Name + " is now online.",
-								Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL},
-								Fields:    onlineData,
-								Footer: &discordgo.MessageEmbedFooter{
-									Text: result.Footer,
-								},
-							},
-						},
+						Embeds: []*discordgo.MessageEmbed{b.onlineEmbed(result, result.Platform)},
 					},
 				})
 				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
+					b.reportError(i.log, "Error responding to interaction", err)
 				}
 
 				_, err = b.Session.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: "Quick Controls for your online session:", Components: onlineControlButtons, Flags: discordgo.MessageFlagsEphemeral})
 				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
+					b.reportError(i.log, "Error sending followup message", err)
 				}
+				b.notifyWatchers(g, result, i.log)
 			} else {
+				content := "Please use the `/online` command only in:"
+				for _, p := range b.Config.Platforms {
+					content += "\n<#" + g.Channels.Platforms[p.Name] + ">"
+				}
 				err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 					Type: discordgo.InteractionResponseChannelMessageWithSource,
 					Data: &discordgo.InteractionResponseData{
-						Content: "Please use the `/online` command only in:\n<#" + b.pcChannelID + ">\n<#" + b.playstationChannelID + ">\n<#" + b.xboxChannelID + ">",
+						Content: content,
 						Flags:   discordgo.MessageFlagsEphemeral,
 					},
 				})
 				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
+					b.reportError(i.log, "Error responding to interaction", err)
 				}
 			}
+			return nil
 		},
-		"offline": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used /offline in channel " + i.ChannelID)
-			var result Player
-			avatarURL := rdoAvatarUnknownURL
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
-			playerOffline := bson.M{
-				"$set": bson.D{
-					{Key: "online", Value: false},
-					{Key: "time", Value: time.Now().Format(time.RFC3339)},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-				},
-			}
-
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, playerOffline).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Content: "You have not set up your profile. \nPlease use </setup:" + b.setupCommandID + "> to start. 🤠",
-							Flags:   discordgo.MessageFlagsEphemeral,
-						},
-					})
-					if err != nil {
-						b.ErrorReport.Notify(err, nil)
-						log.Println(err)
-					}
-				}
-			}
-
-			if result.RockstarId != "" {
-				avatarURL = strings.Join([]string{rdoAvatarURLPrefix, result.RockstarId, rdoAvatarURLSuffix}, "")
-			}
-
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Embeds: []*discordgo.MessageEmbed{
-						{
-							Type:      discordgo.EmbedTypeRich,
-							Color:     colorRed,
-							Title:     result.Name + " is now offline.",
-							Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL},
-						},
-					},
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+		"offline": func(b *Bot, g *guild, i *interaction) error {
+			b.goOffline(g, i)
+			return nil
 		},
-		"show": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used /show in channel " + i.ChannelID)
-			avatarURL := rdoAvatarUnknownURL
-			listPlayers := ""
-			bounty := "-"
-			camp := "-"
-			playTime := "-"
-			footer := ""
-			var cursor *mongo.Cursor
-			var err error
-			var results []Player
-			playerList := []*discordgo.MessageEmbed{}
-
-			opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}})
-
-			switch i.ChannelID {
-			case b.pcChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "PC"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			case b.playstationChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "PS4"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			case b.xboxChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "XBOX"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
-
-			if err = cursor.All(context.TODO(), &results); err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-
-			if len(results) == 0 {
-				playerList = []*discordgo.MessageEmbed{{
-					Type:        discordgo.EmbedTypeRich,
-					Color:       colorDark,
-					Description: "There are no players online at the moment.",
-					Thumbnail: &discordgo.MessageEmbedThumbnail{
-						URL: rdoAvatarUnknownURL,
-					},
-				},
-				}
-			} else {
-				for _, player := range results {
-					if player.RockstarId != "" {
-						avatarURL = strings.Join([]string{rdoAvatarURLPrefix, player.RockstarId, rdoAvatarURLSuffix}, "")
-					}
-					if player.Camp != "" {
-						camp = player.Camp
-					}
-					if player.Bounty != "" {
-						bounty = player.Bounty
-					}
-					if player.Footer != "" {
-						footer = player.Footer
-					}
-					playTime = time.Since(player.Time).Truncate(time.Second).String()
-
-					playerList = append(playerList, &discordgo.MessageEmbed{
-						Type:  discordgo.EmbedTypeRich,
-						Color: colorGrey,
-						Title: player.Name,
-						Thumbnail: &discordgo.MessageEmbedThumbnail{
-							URL: avatarURL,
-						},
-						Fields: []*discordgo.MessageEmbedField{
-							{
-								Name:   "Bounty:",
-								Value:  "$" + bounty,
-								Inline: true,
-							},
-							{
-								Name:   "Camp:",
-								Value:  camp,
-								Inline: true,
-							},
-							{
-								Name:   "Online:",
-								Value:  playTime,
-								Inline: true,
-							},
-						},
-						Footer: &discordgo.MessageEmbedFooter{Text: footer},
-					})
-				}
-			}
-
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Title:   "Online Players",
-					Content: listPlayers,
-					Flags:   discordgo.MessageFlagsEphemeral,
-					Embeds:  playerList,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+		"show": func(b *Bot, g *guild, i *interaction) error {
+			b.showPlayers(g, i)
+			return nil
+		},
+		"profile": func(b *Bot, g *guild, i *interaction) error {
+			return b.showMemberProfile(g, i)
+		},
+		"stats": func(b *Bot, g *guild, i *interaction) error {
+			return b.showStats(g, i)
+		},
+		"leaderboard": func(b *Bot, g *guild, i *interaction) error {
+			return b.showLeaderboard(g, i)
+		},
+		"auto-online": func(b *Bot, g *guild, i *interaction) error {
+			return b.setAutoOnline(g, i)
+		},
+		"event create": func(b *Bot, g *guild, i *interaction) error {
+			return b.createEvent(g, i)
+		},
+		"lfg": func(b *Bot, g *guild, i *interaction) error {
+			return b.lookForGroup(g, i)
+		},
+		"watch add": func(b *Bot, g *guild, i *interaction) error {
+			return b.watchPlayer(g, i)
+		},
+		"watch remove": func(b *Bot, g *guild, i *interaction) error {
+			return b.unwatchPlayer(g, i)
+		},
+		"watch list": func(b *Bot, g *guild, i *interaction) error {
+			return b.showWatchlist(g, i)
+		},
+		"watch mute": func(b *Bot, g *guild, i *interaction) error {
+			return b.muteWatchlist(g, i)
+		},
+		"posse create": func(b *Bot, g *guild, i *interaction) error {
+			return b.createPosse(g, i)
+		},
+		"posse invite": func(b *Bot, g *guild, i *interaction) error {
+			return b.inviteToPosse(g, i)
+		},
+		"posse leave": func(b *Bot, g *guild, i *interaction) error {
+			return b.leavePosse(g, i)
+		},
+		"posse disband": func(b *Bot, g *guild, i *interaction) error {
+			return b.disbandPosse(g, i)
 		},
 	}
 
-	buttonHandlers = map[string]func(b *Bot, i *discordgo.InteractionCreate){
-		"set_bounty": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button set_bounty in channel " + i.ChannelID)
-			err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseModal,
-				Data: &discordgo.InteractionResponseData{
-					Title: "Set Bounty",
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "bounty_input_" + i.Member.User.ID,
-									Label:       "Set your current bounty (0-100):",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "10.01",
-									Required:    true,
-									MinLength:   1,
-									MaxLength:   5,
-								},
-							},
-						},
-					},
-					Flags:    discordgo.MessageFlagsEphemeral,
-					CustomID: "set_bounty_" + i.Member.User.ID,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+	componentHandlers = map[string]handlerFunc{
+		"setup": func(b *Bot, g *guild, i *interaction) error {
+			return b.openSetup(g, i)
+		},
+		"setup:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.openSetup(g, i)
+		},
+		"set_bounty": func(b *Bot, g *guild, i *interaction) error {
+			return b.askBounty(g, i)
+		},
+		"set_bounty:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askBounty(g, i)
+		},
+		"set_camp": func(b *Bot, g *guild, i *interaction) error {
+			return b.askCamp(g, i)
+		},
+		"set_camp:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askCamp(g, i)
+		},
+		"set_footer": func(b *Bot, g *guild, i *interaction) error {
+			return b.askFooter(g, i)
+		},
+		"set_footer:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askFooter(g, i)
 		},
-		"set_camp": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button set_camp in channel " + i.ChannelID)
-			selectMinVal := 1
+		"show_players": func(b *Bot, g *guild, i *interaction) error {
+			b.showPlayers(g, i)
+			return nil
+		},
+		"go_offline": func(b *Bot, g *guild, i *interaction) error {
+			b.goOffline(g, i)
+			return nil
+		},
+		"set_rid": func(b *Bot, g *guild, i *interaction) error {
+			return b.askRockstarID(g, i)
+		},
+		"set_rid:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askRockstarID(g, i)
+		},
+		"set_character": func(b *Bot, g *guild, i *interaction) error {
+			return b.askCharacter(g, i)
+		},
+		"set_character:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askCharacter(g, i)
+		},
+		"set_role_ranks": func(b *Bot, g *guild, i *interaction) error {
+			return b.askRoleRanks(g, i)
+		},
+		"set_role_ranks:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.askRoleRanks(g, i)
+		},
+		"leaderboard:{category}:{period}:{page}": func(b *Bot, g *guild, i *interaction) error {
+			return b.turnLeaderboardPage(g, i)
+		},
+		"event:{eventID}:{rsvp}": func(b *Bot, g *guild, i *interaction) error {
+			return b.respondEvent(i)
+		},
+		"lfg:{groupID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.joinGroup(i)
+		},
+		"posse:{leaderID}:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.joinPosse(g, i)
+		},
+		"still_playing:{guildID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.stillPlaying(i)
+		},
+		"privacy": func(b *Bot, g *guild, i *interaction) error {
+			return b.showPrivacy(g, i)
+		},
+		"privacy:{field}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setPrivacy(g, i)
+		},
+		"camp_selection": func(b *Bot, g *guild, i *interaction) error {
+			return b.selectCamp(g, i)
+		},
+		"camp_selection:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.selectCamp(g, i)
+		},
+	}
 
-			err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Set your current camp location.\nYour profile will be updated as soon as you select an option.",
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.SelectMenu{
-									MenuType:    3,
-									Placeholder: "Choose Location",
-									MinValues:   &selectMinVal,
-									MaxValues:   1,
-									CustomID:    "camp_selection",
-									Options: []discordgo.SelectMenuOption{
-										{
-											Label: "Bayou Nwa",
-											Value: "Bayou Nwa",
-										},
-										{
-											Label: "Big Valley",
-											Value: "Big Valley",
-										},
-										{
-											Label: "Cholla Springs",
-											Value: "Cholla Springs",
-										},
-										{
-											Label: "Cumberland Forest",
-											Value: "Cumberland Forest",
-										},
-										{
-											Label: "Gaptooth Ridge",
-											Value: "Gaptooth Ridge",
-										},
-										{
-											Label: "Great Plains",
-											Value: "Great Plains",
-										},
-										{
-											Label: "Grizzlies",
-											Value: "Grizzlies",
-										},
-										{
-											Label: "Heartlands",
-											Value: "Heartlands",
-										},
-										{
-											Label: "Hennigan's Stead",
-											Value: "Hennigan's Stead",
-										},
-										{
-											Label: "Rio Bravo",
-											Value: "Rio Bravo",
-										},
-										{
-											Label: "Roanoke Ridge",
-											Value: "Roanoke Ridge",
-										},
-										{
-											Label: "Scarlett Meadows",
-											Value: "Scarlett Meadows",
-										},
-										{
-											Label: "Tall Trees",
-											Value: "Tall Trees",
-										},
-									},
-								},
-							},
-						},
-					},
-					Flags:    discordgo.MessageFlagsEphemeral,
-					CustomID: "select_camp_" + i.Member.User.ID,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+	modalHandlers = map[string]handlerFunc{
+		"setup:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.submitSetup(g, i)
 		},
-		"set_footer": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button set_footer in channel " + i.ChannelID)
-			err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseModal,
-				Data: &discordgo.InteractionResponseData{
-					Title:   "Footer Message",
-					Content: "Enter a message that appears in the footer of your online notification.",
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "footer_input_" + i.Member.User.ID,
-									Label:       "Set your footer message",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "What are you up to?",
-									Required:    false,
-									MaxLength:   42,
-								},
-							},
-						},
-					},
-					Flags:    discordgo.MessageFlagsEphemeral,
-					CustomID: "set_footer_" + i.Member.User.ID,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+		"setup:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.submitSetup(g, i)
+		},
+		"set_footer:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setFooter(g, i)
+		},
+		"set_footer:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setFooter(g, i)
+		},
+		"set_bounty:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setBounty(g, i)
+		},
+		"set_bounty:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setBounty(g, i)
+		},
+		"set_rid:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setRockstarID(g, i)
+		},
+		"set_rid:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setRockstarID(g, i)
+		},
+		"set_character:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setCharacter(g, i)
+		},
+		"set_character:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setCharacter(g, i)
 		},
-		"show_players": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button show_players in channel " + i.ChannelID)
-			avatarURL := rdoAvatarUnknownURL
-			listPlayers := ""
-			bounty := "-"
-			camp := "-"
-			playTime := "-"
-			footer := ""
-			var cursor *mongo.Cursor
-			var err error
-			var results []Player
-			playerList := []*discordgo.MessageEmbed{}
+		"set_role_ranks:{userID}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setRoleRanks(g, i)
+		},
+		"set_role_ranks:{userID}:{platform}": func(b *Bot, g *guild, i *interaction) error {
+			return b.setRoleRanks(g, i)
+		},
+	}
 
-			opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}})
+	interactionRouter = newRouter(commandHandlers, componentHandlers, modalHandlers)
+)
 
-			switch i.ChannelID {
-			case b.pcChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "PC"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			case b.playstationChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "PS4"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			case b.xboxChannelID:
-				cursor, err = b.Collection.Find(context.TODO(), bson.D{{Key: "platform", Value: "XBOX"}, {Key: "online", Value: true}}, opts)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
+func (b *Bot) registerCommands(s *discordgo.Session, ic *discordgo.InteractionCreate) {
+	// Interactions in DMs have no server, their handlers look it up themselves
+	g := b.guild(ic.GuildID)
+	if g == nil && ic.GuildID != "" {
+		return
+	}
 
-			if err = cursor.All(context.TODO(), &results); err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+	start := time.Now()
+	i := newInteraction(ic)
+	h, ok := interactionRouter.resolve(i)
+	if !ok {
+		i.log.Warn("Unknown interaction")
+		return
+	}
+	i.log = i.log.With("name", i.name)
 
-			if len(results) == 0 {
-				playerList = []*discordgo.MessageEmbed{{
-					Type:        discordgo.EmbedTypeRich,
-					Color:       colorDark,
-					Description: "There are no players online at the moment.",
-					Thumbnail: &discordgo.MessageEmbedThumbnail{
-						URL: rdoAvatarUnknownURL,
-					},
-				},
-				}
-			} else {
-				for _, player := range results {
-					if player.RockstarId != "" {
-						avatarURL = strings.Join([]string{rdoAvatarURLPrefix, player.RockstarId, rdoAvatarURLSuffix}, "")
-					}
-					if player.Camp != "" {
-						camp = player.Camp
-					}
-					if player.Bounty != "" {
-						bounty = player.Bounty
-					}
-					if player.Footer != "" {
-						footer = player.Footer
-					}
-					playTime = time.Since(player.Time).Truncate(time.Second).String()
+	var err error
+	if i.Type == discordgo.InteractionModalSubmit {
+		i.fields, err = parseModalFields(i.ModalSubmitData())
+	}
+	if err == nil {
+		err = h(b, g, i)
+	}
+	if err != nil {
+		b.reportError(i.log, "Error handling interaction", err)
+		b.respondError(i)
+	}
 
-					playerList = append(playerList, &discordgo.MessageEmbed{
-						Type:  discordgo.EmbedTypeRich,
-						Color: colorGrey,
-						Title: player.Name,
-						Thumbnail: &discordgo.MessageEmbedThumbnail{
-							URL: avatarURL,
-						},
-						Fields: []*discordgo.MessageEmbedField{
-							{
-								Name:   "Bounty:",
-								Value:  "$" + bounty,
-								Inline: true,
-							},
-							{
-								Name:   "Camp:",
-								Value:  camp,
-								Inline: true,
-							},
-							{
-								Name:   "Online:",
-								Value:  playTime,
-								Inline: true,
-							},
-						},
-						Footer: &discordgo.MessageEmbedFooter{Text: footer},
-					})
-				}
-			}
+	b.metrics.observeInteraction(i.kind, i.name, start)
+	i.log.Info("Interaction handled", "duration", time.Since(start))
+}
 
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Title:   "Online Players",
-					Content: listPlayers,
-					Flags:   discordgo.MessageFlagsEphemeral,
-					Embeds:  playerList,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+// respondError tells the user that their interaction could not be handled.
+func (b *Bot) respondError(i *interaction) {
+	err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Sorry, something went wrong. Please try again later.",
+			Flags:   discordgo.MessageFlagsEphemeral,
 		},
-		"go_offline": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button go_offline in channel " + i.ChannelID)
-			var result Player
-			avatarURL := rdoAvatarUnknownURL
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
-			playerOffline := bson.M{
-				"$set": bson.D{
-					{Key: "online", Value: false},
-					{Key: "time", Value: time.Now().Format(time.RFC3339)},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-				},
-			}
-
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, playerOffline).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Content: "You have not set up your profile. \nPlease use </setup:" + b.setupCommandID + "> to start. 🤠",
-							Flags:   discordgo.MessageFlagsEphemeral,
-						},
-					})
-					if err != nil {
-						b.ErrorReport.Notify(err, nil)
-						log.Println(err)
-					}
-				}
-			}
-
-			if result.RockstarId != "" {
-				avatarURL = strings.Join([]string{rdoAvatarURLPrefix, result.RockstarId, rdoAvatarURLSuffix}, "")
-			}
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+}
 
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Embeds: []*discordgo.MessageEmbed{
-						{
-							Type:      discordgo.EmbedTypeRich,
-							Color:     colorRed,
-							Title:     result.Name + " is now offline.",
-							Thumbnail: &discordgo.MessageEmbedThumbnail{URL: avatarURL},
-						},
-					},
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+// respondEphemeral answers the interaction with a message only the user sees.
+func (b *Bot) respondEphemeral(i *interaction, content string) {
+	err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
 		},
-		"set_rid": func(b *Bot, i *discordgo.InteractionCreate) {
-			log.Println(i.Member.User.Username + " used button set_rid in channel " + i.ChannelID)
-			err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseModal,
-				Data: &discordgo.InteractionResponseData{
-					Title: "Set Rockstar ID",
-					Components: []discordgo.MessageComponent{
-						discordgo.ActionsRow{
-							Components: []discordgo.MessageComponent{
-								discordgo.TextInput{
-									CustomID:    "rid_input_" + i.Member.User.ID,
-									Label:       "Copy & Paste your R* ID:",
-									Style:       discordgo.TextInputShort,
-									Placeholder: "123456789",
-									Required:    false,
-									MaxLength:   9,
-								},
-							},
-						},
-					},
-					Flags:    discordgo.MessageFlagsEphemeral,
-					CustomID: "set_rid_" + i.Member.User.ID,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+}
+
+func (b *Bot) respondSetupRequired(g *guild, i *interaction) {
+	err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "You have not set up your profile. \nPlease use " + g.commandMention("setup") + " to start. 🤠",
+			Flags:   discordgo.MessageFlagsEphemeral,
 		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
 	}
-)
+}
 
-func (b *Bot) registerCommands(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	switch i.Type {
-	case discordgo.InteractionApplicationCommand:
-		if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
-			h(b, i)
+func (b *Bot) goOffline(g *guild, i *interaction) {
+	result, err := b.setOffline(context.TODO(), g.player(i.Member.User.ID), i.log)
+	if err != nil {
+		if err == ErrPlayerNotFound {
+			b.respondSetupRequired(g, i)
+		} else {
+			b.reportError(i.log, "Error setting player offline", err)
 		}
-	case discordgo.InteractionMessageComponent:
-		if strings.HasPrefix(i.MessageComponentData().CustomID, "camp_selection") {
-			camp := i.MessageComponentData().Values[0]
+		return
+	}
 
-			var result Player
-			player := bson.M{
-				"$set": bson.D{
-					{Key: "camp", Value: strings.Trim(camp, " ")},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-				},
-			}
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.offlineEmbed(result)},
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+}
 
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, player).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
+// onlineEmbed announces p going online to viewers on the given platform,
+// empty outside of platform channels.
+func (b *Bot) onlineEmbed(p *Player, viewer string) *discordgo.MessageEmbed {
+	view := b.view(p, viewer)
+	return &discordgo.MessageEmbed{
+		Type:      discordgo.EmbedTypeRich,
+		Color:     colorGreen,
+		Title:     p.Name + " is now online.",
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: view.Avatar},
+		Fields: append([]*discordgo.MessageEmbedField{
+			{
+				Name:   "Bounty:",
+				Value:  view.Bounty,
+				Inline: true,
+			},
+			{
+				Name:   "Camp:",
+				Value:  view.Camp,
+				Inline: true,
+			},
+			{
+				Name:   "Platform:",
+				Value:  p.Platform,
+				Inline: true,
+			},
+		}, b.characterFields(p.Profile)...),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: view.Footer,
+		},
+	}
+}
 
-			err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Your camp location is now set to **" + camp + "**",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-		}
+// offlineEmbed is posted in the channel of the platform p was online on.
+func (b *Bot) offlineEmbed(p *Player) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Type:      discordgo.EmbedTypeRich,
+		Color:     colorRed,
+		Title:     p.Name + " is now offline.",
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: b.view(p, p.Platform).Avatar},
+	}
+}
 
-		if h, ok := buttonHandlers[i.MessageComponentData().CustomID]; ok {
-			h(b, i)
-		}
-	case discordgo.InteractionModalSubmit:
-		modalData := i.ModalSubmitData()
-		if strings.HasPrefix(modalData.CustomID, "setup") {
-			rockstarId := modalData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
-			bounty := modalData.Components[2].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
-			footer := modalData.Components[3].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+func (b *Bot) showPlayers(g *guild, i *interaction) {
+	var results []Player
+	var err error
+	playerList := []*discordgo.MessageEmbed{}
 
-			var result Player
-			var player bson.D
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
+	platform, ok := g.platform(i.ChannelID)
+	if ok {
+		results, err = b.Players.OnlinePlayers(context.TODO(), g.Namespace, platform)
+		if err != nil {
+			b.reportError(i.log, "Error reading online players", err)
+		}
+	}
 
-			player = append(player, bson.E{Key: "discord_id", Value: i.Member.User.ID})
-			if i.Member.Nick != "" {
-				player = append(player, bson.E{Key: "name", Value: i.Member.Nick})
+	if len(results) == 0 {
+		playerList = []*discordgo.MessageEmbed{{
+			Type:        discordgo.EmbedTypeRich,
+			Color:       colorDark,
+			Description: "There are no players online at the moment.",
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL: rdoAvatarUnknownURL,
+			},
+		},
+		}
+	} else {
+		for _, group := range groupPosses(results) {
+			if len(group) > 1 {
+				playerList = append(playerList, b.posseEmbed(group, platform))
 			} else {
-				player = append(player, bson.E{Key: "name", Value: i.Member.User.Username})
-			}
-			player = append(player, bson.E{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)})
-
-			if rockstarId != "" {
-				player = append(player, bson.E{Key: "rockstar_id", Value: strings.Trim(rockstarId, " ")})
+				playerList = append(playerList, b.playerEmbed(&group[0], platform))
 			}
-			if bounty != "" {
-				player = append(player, bson.E{Key: "bounty", Value: strings.Trim(bounty, " ")})
-			}
-			if footer != "" {
-				player = append(player, bson.E{Key: "footer", Value: strings.Trim(footer, " ")})
-			}
-
-			err := b.Collection.FindOne(context.TODO(), filter).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					_, err = b.Collection.InsertOne(context.TODO(), player)
-					if err != nil {
-						b.ErrorReport.Notify(err, nil)
-						log.Println(err)
-					}
-
-					err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-						Type: discordgo.InteractionResponseChannelMessageWithSource,
-						Data: &discordgo.InteractionResponseData{
-							Content: "Success! Your initial profile info is now set. You can now go online, offline and show other online players.",
-							Flags:   discordgo.MessageFlagsEphemeral,
-						},
-					})
-					if err != nil {
-						b.ErrorReport.Notify(err, nil)
-						log.Println(err)
-					}
-				}
-			} else {
-				playerUpdate := bson.M{
-					"$set": player,
-				}
+		}
+	}
 
-				_, err = b.Collection.UpdateOne(context.TODO(), filter, playerUpdate)
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Title:  "Online Players",
+			Flags:  discordgo.MessageFlagsEphemeral,
+			Embeds: playerList,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+}
 
-				err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-					Type: discordgo.InteractionResponseChannelMessageWithSource,
-					Data: &discordgo.InteractionResponseData{
-						Content: "Success! Your profile has been updated.",
-						Flags:   discordgo.MessageFlagsEphemeral,
-					},
-				})
-				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
-		} else if strings.HasPrefix(modalData.CustomID, "set_footer") {
-			footer := modalData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+func (b *Bot) playerEmbed(player *Player, viewer string) *discordgo.MessageEmbed {
+	view := b.view(player, viewer)
+	playTime := time.Since(player.Time).Truncate(time.Second).String()
 
-			var result Player
-			player := bson.M{
-				"$set": bson.D{
-					{Key: "footer", Value: strings.Trim(footer, " ")},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-				},
-			}
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
+	return &discordgo.MessageEmbed{
+		Type:  discordgo.EmbedTypeRich,
+		Color: colorGrey,
+		Title: player.Name,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: view.Avatar,
+		},
+		Fields: append([]*discordgo.MessageEmbedField{
+			{
+				Name:   "Bounty:",
+				Value:  view.Bounty,
+				Inline: true,
+			},
+			{
+				Name:   "Camp:",
+				Value:  view.Camp,
+				Inline: true,
+			},
+			{
+				Name:   "Online:",
+				Value:  playTime,
+				Inline: true,
+			},
+		}, b.characterFields(player.Profile)...),
+		Footer: &discordgo.MessageEmbedFooter{Text: view.Footer},
+	}
+}
 
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, player).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
+// maxBounty is the highest bounty a player can have in the game.
+const maxBounty = 100
 
-			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Your footer message is set. Feel free to change it anytime.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-		} else if strings.HasPrefix(modalData.CustomID, "set_bounty") {
-			bounty := modalData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+// maxRank bounds the overall rank, which has no limit in the game, and
+// maxRoleRank is the highest rank of a role.
+const (
+	maxRank     = 9999
+	maxRoleRank = 20
+)
 
-			var result Player
-			player := bson.M{
-				"$set": bson.D{
-					{Key: "bounty", Value: strings.Trim(bounty, " ")},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
+// respondRetry answers a modal whose input was invalid. Modals cannot be
+// answered with another modal, so the button with the custom ID retry opens
+// it again.
+func (b *Bot) respondRetry(i *interaction, content, retry string) {
+	err := b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: "Try again", Style: discordgo.PrimaryButton, CustomID: retry},
+					},
 				},
-			}
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+}
 
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, player).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
+// respondInvalidBounty explains what is wrong with an entered bounty.
+func (b *Bot) respondInvalidBounty(i *interaction, err error, retry string) {
+	content := "Please enter your bounty as a number like `12.50`."
+	if err == errBountyRange {
+		content = "Bounties range from " + formatBounty(0) + " to " + formatBounty(maxBounty) + ", please enter your bounty within that range."
+	}
+	b.respondRetry(i, content, retry)
+}
 
-			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Your bounty is now set to **$" + bounty + "**",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-		} else if strings.HasPrefix(modalData.CustomID, "set_rid") {
-			rockstarId := modalData.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+// respondInvalidRockstarID explains the format of R* IDs.
+func (b *Bot) respondInvalidRockstarID(i *interaction, retry string) {
+	b.respondRetry(i, "Your R* ID is the 9 digit number shown on your Social Club profile, like `123456789`.", retry)
+}
 
-			var result Player
-			player := bson.M{
-				"$set": bson.D{
-					{Key: "rockstar_id", Value: rockstarId},
-					{Key: "expires", Value: time.Now().Add(time.Hour * 24 * 365)},
-				},
-			}
-			filter := bson.D{{Key: "discord_id", Value: i.Member.User.ID}}
+// bountyPattern matches bounties like 12.50, $12.5, 12,50 or -$5, which
+// are checked for their range afterwards.
+var bountyPattern = regexp.MustCompile(`^([-+]?)\$?(\d+([.,]\d{1,2})?)$`)
 
-			err := b.Collection.FindOneAndUpdate(context.TODO(), filter, player).Decode(&result)
-			if err != nil {
-				if err == mongo.ErrNoDocuments {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
-				}
-			}
+// parseBounty reads a bounty in dollars with up to two decimal places.
+func parseBounty(s string) (float64, error) {
+	match := bountyPattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, errBountyInvalid
+	}
+	bounty, err := strconv.ParseFloat(match[1]+strings.Replace(match[2], ",", ".", 1), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, errBountyInvalid
+	}
+	if bounty < 0 || bounty > maxBounty {
+		return 0, errBountyRange
+	}
+	return bounty, nil
+}
 
-			err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Successfully updated your Rockstar ID.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
-			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
-			}
-		}
+// parseRank reads a rank from 1 to max, or 0 if s is empty.
+func parseRank(s string, max int) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	rank, err := strconv.Atoi(s)
+	if err != nil || rank < 1 || rank > max {
+		return 0, errRankInvalid
 	}
+	return rank, nil
+}
+
+func formatBounty(bounty float64) string {
+	return "$" + strconv.FormatFloat(bounty, 'f', 2, 64)
 }
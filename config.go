@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfig is used when no config file is present next to the binary.
+//
+//go:embed config.yaml
+var defaultConfig []byte
+
+type Config struct {
+	Channels  ChannelNames     `yaml:"channels"`
+	Platforms []PlatformConfig `yaml:"platforms"`
+	Roles     []RoleConfig     `yaml:"roles"`
+	Camps     []string         `yaml:"camps"`
+	Sessions  SessionConfig    `yaml:"sessions"`
+}
+
+type ChannelNames struct {
+	General  string `yaml:"general"`
+	Roles    string `yaml:"roles"`
+	Commands string `yaml:"commands"`
+	Bulletin string `yaml:"bulletin"`
+}
+
+type PlatformConfig struct {
+	Name    string `yaml:"name"`
+	Label   string `yaml:"label"`
+	Channel string `yaml:"channel"`
+	Emoji   string `yaml:"emoji"`
+}
+
+// SessionConfig limits how long players stay online without telling the bot
+// they are still playing. Zero durations disable the respective limit.
+type SessionConfig struct {
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	MaxLength   time.Duration `yaml:"max_length"`
+	Reminder    time.Duration `yaml:"reminder"`
+}
+
+type RoleConfig struct {
+	Name  string `yaml:"name"`
+	Label string `yaml:"label"`
+	Emoji string `yaml:"emoji"`
+}
+
+// Discord limits select menus to 25 options
+const maxCamps = 25
+
+func readConfig(path string) *Config {
+	slog.Info("Reading config file...", "path", path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Info("No config file found, using defaults...", "path", path)
+		data = defaultConfig
+	} else if err != nil {
+		fatal("Error reading config file", err)
+	}
+
+	config, err := parseConfig(data)
+	if err != nil {
+		fatal("Invalid config file", err)
+	}
+	return config
+}
+
+func parseConfig(data []byte) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	for i := range c.Platforms {
+		if c.Platforms[i].Label == "" {
+			c.Platforms[i].Label = c.Platforms[i].Name
+		}
+	}
+	for i := range c.Roles {
+		if c.Roles[i].Label == "" {
+			c.Roles[i].Label = c.Roles[i].Name
+		}
+	}
+	return &c, nil
+}
+
+// validate reports every invalid entry at once, each prefixed with its path.
+func (c *Config) validate() error {
+	var errs []error
+	invalid := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	channels := make(map[string]string)
+	checkChannel := func(path, name string) {
+		if name == "" {
+			invalid(path, "must not be empty")
+			return
+		}
+		if name != strings.ToLower(name) || strings.ContainsAny(name, " #") {
+			invalid(path, "%q is not a valid channel name", name)
+		}
+		if other, ok := channels[name]; ok {
+			invalid(path, "channel %q is already used by %s", name, other)
+			return
+		}
+		channels[name] = path
+	}
+	checkChannel("channels.general", c.Channels.General)
+	checkChannel("channels.roles", c.Channels.Roles)
+	checkChannel("channels.commands", c.Channels.Commands)
+	checkChannel("channels.bulletin", c.Channels.Bulletin)
+
+	// Platform and role names share the server roles, so they must be unique across both
+	names := make(map[string]string)
+	emojis := make(map[string]string)
+	checkRole := func(path, name, emoji string) {
+		if name == "" {
+			invalid(path+".name", "must not be empty")
+		} else if other, ok := names[name]; ok {
+			invalid(path+".name", "%q is already used by %s", name, other)
+		} else {
+			names[name] = path
+		}
+		if emoji == "" {
+			invalid(path+".emoji", "must not be empty")
+		} else if other, ok := emojis[emoji]; ok {
+			invalid(path+".emoji", "%q is already used by %s", emoji, other)
+		} else {
+			emojis[emoji] = path
+		}
+	}
+
+	if len(c.Platforms) == 0 {
+		invalid("platforms", "at least one platform is required")
+	}
+	for i, p := range c.Platforms {
+		path := fmt.Sprintf("platforms[%d]", i)
+		checkRole(path, p.Name, p.Emoji)
+		// Platform names are segments of custom IDs and database field paths
+		if strings.ContainsAny(p.Name, ":.$") {
+			invalid(path+".name", "%q must not contain ':', '.' or '$'", p.Name)
+		}
+		checkChannel(path+".channel", p.Channel)
+	}
+	for i, r := range c.Roles {
+		checkRole(fmt.Sprintf("roles[%d]", i), r.Name, r.Emoji)
+	}
+
+	if len(c.Camps) == 0 {
+		invalid("camps", "at least one camp is required")
+	}
+	if len(c.Camps) > maxCamps {
+		invalid("camps", "%d camps given, at most %d are supported", len(c.Camps), maxCamps)
+	}
+	camps := make(map[string]int)
+	for i, camp := range c.Camps {
+		path := fmt.Sprintf("camps[%d]", i)
+		if strings.TrimSpace(camp) == "" {
+			invalid(path, "must not be empty")
+		} else if other, ok := camps[camp]; ok {
+			invalid(path, "%q is already listed as camps[%d]", camp, other)
+		} else {
+			camps[camp] = i
+		}
+	}
+
+	s := c.Sessions
+	checkDuration := func(path string, d time.Duration) {
+		if d < 0 {
+			invalid(path, "must not be negative")
+		}
+	}
+	checkDuration("sessions.idle_timeout", s.IdleTimeout)
+	checkDuration("sessions.max_length", s.MaxLength)
+	checkDuration("sessions.reminder", s.Reminder)
+	if s.Reminder > 0 {
+		if !s.enabled() {
+			invalid("sessions.reminder", "requires idle_timeout or max_length")
+		} else if (s.IdleTimeout > 0 && s.Reminder >= s.IdleTimeout) || (s.MaxLength > 0 && s.Reminder >= s.MaxLength) {
+			invalid("sessions.reminder", "must be shorter than idle_timeout and max_length")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// roleEmoji returns the emoji used to self assign the server role with the given name.
+func (c *Config) roleEmoji(name string) string {
+	for _, p := range c.Platforms {
+		if p.Name == name {
+			return p.Emoji
+		}
+	}
+	for _, r := range c.Roles {
+		if r.Name == name {
+			return r.Emoji
+		}
+	}
+	return ""
+}
+
+// platformLabel returns the display name of the platform with the given name.
+func (c *Config) platformLabel(name string) string {
+	for _, p := range c.Platforms {
+		if p.Name == name {
+			return p.Label
+		}
+	}
+	return name
+}
+
+// findPlatform returns the name of the platform with the given name or
+// label, ignoring case.
+func (c *Config) findPlatform(s string) (string, bool) {
+	for _, p := range c.Platforms {
+		if strings.EqualFold(p.Name, s) || strings.EqualFold(p.Label, s) {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// findRole returns the self-assignable role with the given name.
+func (c *Config) findRole(name string) (RoleConfig, bool) {
+	for _, r := range c.Roles {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return RoleConfig{}, false
+}
+
+// roleDescription lists the self-assignable roles with their emojis.
+func (c *Config) roleDescription() string {
+	description := "React to this message to assign your roles:"
+	for _, r := range c.Roles {
+		description += "\n\n" + r.Emoji + " " + r.Label
+	}
+	for _, p := range c.Platforms {
+		description += "\n\n" + p.Emoji + " " + p.Label
+	}
+	return description
+}
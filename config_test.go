@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePlatformNames(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"PS:4", "PS.4", "$PC"} {
+		c, err := parseConfig(defaultConfig)
+		if err != nil {
+			t.Fatalf("parsing default config: %v", err)
+		}
+		c.Platforms[0].Name = name
+		if err := c.validate(); err == nil || !strings.Contains(err.Error(), "platforms[0].name") {
+			t.Errorf("platform name %q passed validation with %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,866 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/airbrake/gobrake/v5"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testGuildID = "100"
+	testBotID   = "200"
+	testBotRole = "RDO Bot"
+
+	// syncEvent is dispatched after every event. Handlers run synchronously in
+	// order, so once it arrives all handlers of the previous event returned.
+	syncEvent = "RDO_TEST_SYNC"
+)
+
+// fakeDiscord imitates the parts of the Discord REST API and gateway the bot
+// uses and records everything the bot does.
+type fakeDiscord struct {
+	t      *testing.T
+	server *httptest.Server
+	routes []fakeRoute
+
+	mu          sync.Mutex
+	lastID      int
+	channels    []*discordgo.Channel
+	dmChannels  map[string]string
+	roles       []*discordgo.Role
+	messages    map[string][]*discordgo.Message
+	reactions   map[string][]string
+	memberRoles map[string][]string
+	commands    []*discordgo.ApplicationCommand
+	responses   map[string]*fakeResponse
+	reported    []string
+
+	wsMu   sync.Mutex
+	conn   *websocket.Conn
+	seq    int
+	synced chan struct{}
+}
+
+// fakeResponse is the answer to an interaction received by the fake server.
+type fakeResponse struct {
+	Type      discordgo.InteractionResponseType
+	Message   *discordgo.Message // content, embeds, components and flags
+	CustomID  string             // custom ID of a modal
+	Title     string
+	Followups []*discordgo.Message
+}
+
+type fakeRoute struct {
+	method  string
+	pattern []string
+	handler func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+// newTestBot starts a fake Discord server with a guild laid out according to
+// the default config and connects a bot with in-memory stores to it.
+func newTestBot(t *testing.T) (*Bot, *fakeDiscord) {
+	t.Helper()
+
+	config, err := parseConfig(defaultConfig)
+	if err != nil {
+		t.Fatalf("parsing default config: %v", err)
+	}
+
+	f := &fakeDiscord{
+		t:           t,
+		messages:    make(map[string][]*discordgo.Message),
+		reactions:   make(map[string][]string),
+		memberRoles: make(map[string][]string),
+		dmChannels:  make(map[string]string),
+		responses:   make(map[string]*fakeResponse),
+		synced:      make(chan struct{}),
+	}
+	for _, name := range []string{config.Channels.General, config.Channels.Roles, config.Channels.Commands} {
+		f.addChannel(name)
+	}
+	for _, p := range config.Platforms {
+		f.addChannel(p.Channel)
+	}
+	for _, name := range []string{"@everyone", testBotRole} {
+		f.addRole(name)
+	}
+	for _, r := range config.Roles {
+		f.addRole(r.Name)
+	}
+	f.routeAPI()
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+
+	session, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+	target, _ := url.Parse(f.server.URL)
+	session.Client = &http.Client{Transport: rewriteTransport{target: target, next: f.server.Client().Transport}}
+	session.SyncEvents = true
+	session.ShouldReconnectOnError = false
+	session.AddHandler(func(s *discordgo.Session, e *discordgo.Event) {
+		if e.Type == syncEvent {
+			f.synced <- struct{}{}
+		}
+	})
+
+	b := &Bot{
+		Session:  session,
+		Players:  newMemoryPlayerStore(),
+		Sessions: newMemorySessionStore(),
+		Guilds:   newMemoryGuildStore(),
+		Events:   newMemoryEventStore(),
+		Groups:   newMemoryGroupStore(),
+		Watches:  newMemoryWatchStore(),
+		Config:   config,
+		BotRole:  testBotRole,
+		Avatars:  newAvatars(rdoAvatarURLPrefix, false),
+		guilds:   make(map[string]*guild),
+		ErrorReport: gobrake.NewNotifierWithOptions(&gobrake.NotifierOptions{
+			ProjectId:           1,
+			ProjectKey:          "test",
+			Environment:         "test",
+			DisableRemoteConfig: true,
+			DisableAPM:          true,
+			DisableCodeHunks:    true,
+		}),
+	}
+	b.ErrorReport.AddFilter(func(n *gobrake.Notice) *gobrake.Notice {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, e := range n.Errors {
+			f.reported = append(f.reported, e.Message)
+		}
+		return nil
+	})
+	b.metrics = newMetrics(b)
+	b.addHandlers()
+
+	if err := session.Open(); err != nil {
+		t.Fatalf("opening session: %v", err)
+	}
+	t.Cleanup(func() {
+		session.Close()
+		b.ErrorReport.Close()
+		for _, msg := range f.reportedErrors() {
+			t.Errorf("bot reported error: %s", msg)
+		}
+	})
+
+	if !b.prepared.Load() {
+		t.Fatal("bot did not finish setup on READY")
+	}
+	return b, f
+}
+
+// rewriteTransport sends all requests to the fake server.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = t.target.Host
+	return t.next.RoundTrip(r)
+}
+
+func (f *fakeDiscord) newID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastID++
+	// Snowflakes have 19 digits, which matters for the length of mentions
+	return strconv.FormatInt(1100000000000000000+int64(f.lastID), 10)
+}
+
+func (f *fakeDiscord) addChannel(name string) *discordgo.Channel {
+	c := &discordgo.Channel{ID: f.newID(), GuildID: testGuildID, Name: name, Type: discordgo.ChannelTypeGuildText}
+	f.channels = append(f.channels, c)
+	return c
+}
+
+func (f *fakeDiscord) addRole(name string) *discordgo.Role {
+	r := &discordgo.Role{ID: f.newID(), Name: name}
+	f.roles = append(f.roles, r)
+	return r
+}
+
+// channelID returns the ID of the channel with the given name.
+func (f *fakeDiscord) channelID(name string) string {
+	for _, c := range f.channels {
+		if c.Name == name {
+			return c.ID
+		}
+	}
+	f.t.Fatalf("no channel %q", name)
+	return ""
+}
+
+// dmChannelID returns the ID of the DM channel with a user, creating it if
+// necessary.
+func (f *fakeDiscord) dmChannelID(userID string) string {
+	f.mu.Lock()
+	for id, recipient := range f.dmChannels {
+		if recipient == userID {
+			f.mu.Unlock()
+			return id
+		}
+	}
+	f.mu.Unlock()
+
+	id := f.newID()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dmChannels[id] = userID
+	return id
+}
+
+// roleID returns the ID of the role with the given name.
+func (f *fakeDiscord) roleID(name string) string {
+	for _, r := range f.roles {
+		if r.Name == name {
+			return r.ID
+		}
+	}
+	f.t.Fatalf("no role %q", name)
+	return ""
+}
+
+// channelMessages returns the messages in a channel, oldest first.
+func (f *fakeDiscord) channelMessages(channelID string) []*discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*discordgo.Message(nil), f.messages[channelID]...)
+}
+
+// messageReactions returns the emojis the bot reacted with to a message.
+func (f *fakeDiscord) messageReactions(messageID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.reactions[messageID]...)
+}
+
+// members returns the members with roles.
+func (f *fakeDiscord) members() []*discordgo.Member {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	members := []*discordgo.Member{}
+	for id, roles := range f.memberRoles {
+		members = append(members, &discordgo.Member{GuildID: testGuildID, User: &discordgo.User{ID: id}, Roles: roles})
+	}
+	return members
+}
+
+// setRoles replaces the roles of a member and announces the change.
+func (f *fakeDiscord) setRoles(userID string, roles ...string) {
+	f.t.Helper()
+	f.mu.Lock()
+	f.memberRoles[userID] = roles
+	f.mu.Unlock()
+	f.dispatch("GUILD_MEMBER_UPDATE", map[string]any{"guild_id": testGuildID, "user": map[string]any{"id": userID}, "roles": roles})
+}
+
+// rolesOf returns the sorted role IDs assigned to a member.
+func (f *fakeDiscord) rolesOf(userID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	roles := append([]string(nil), f.memberRoles[userID]...)
+	sort.Strings(roles)
+	return roles
+}
+
+func (f *fakeDiscord) registeredCommands() []*discordgo.ApplicationCommand {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commands
+}
+
+func (f *fakeDiscord) reportedErrors() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reported
+}
+
+// waitFollowups waits until the bot sent at least n followup messages to an
+// interaction and returns them.
+func (f *fakeDiscord) waitFollowups(res *fakeResponse, n int) []*discordgo.Message {
+	f.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.mu.Lock()
+		followups := append([]*discordgo.Message(nil), res.Followups...)
+		f.mu.Unlock()
+		if len(followups) >= n {
+			return followups
+		}
+		if time.Now().After(deadline) {
+			f.t.Fatalf("got %d followups, want %d", len(followups), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// takeReportedErrors returns the errors reported so far, which no longer
+// fail the test. Notices arrive asynchronously, so flush the notifier first.
+func (f *fakeDiscord) takeReportedErrors() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reported := f.reported
+	f.reported = nil
+	return reported
+}
+
+// Gateway
+
+func (f *fakeDiscord) serveGateway(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		f.t.Errorf("upgrading gateway connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	f.wsMu.Lock()
+	f.conn = conn
+	f.wsMu.Unlock()
+
+	f.send(10, "", map[string]any{"heartbeat_interval": 45000})
+	for {
+		var p struct {
+			Op int `json:"op"`
+		}
+		if err := conn.ReadJSON(&p); err != nil {
+			return
+		}
+
+		switch p.Op {
+		case 1:
+			f.send(11, "", nil)
+		case 8:
+			f.send(0, "GUILD_MEMBERS_CHUNK", map[string]any{"guild_id": testGuildID, "members": f.members(), "chunk_index": 0, "chunk_count": 1})
+		case 2:
+			f.send(0, "READY", map[string]any{
+				"v":          10,
+				"session_id": "session",
+				"user":       discordgo.User{ID: testBotID, Username: "rdo-bot", Bot: true},
+				"guilds":     []map[string]any{{"id": testGuildID, "unavailable": true}},
+			})
+		}
+	}
+}
+
+func (f *fakeDiscord) send(op int, eventType string, data any) {
+	f.wsMu.Lock()
+	defer f.wsMu.Unlock()
+
+	p := map[string]any{"op": op, "d": data}
+	if op == 0 {
+		f.seq++
+		p["t"] = eventType
+		p["s"] = f.seq
+	}
+	if err := f.conn.WriteJSON(p); err != nil {
+		f.t.Errorf("writing gateway event: %v", err)
+	}
+}
+
+// dispatch sends an event over the gateway and waits until all handlers ran.
+func (f *fakeDiscord) dispatch(eventType string, data any) {
+	f.t.Helper()
+	f.send(0, eventType, data)
+	f.send(0, syncEvent, nil)
+	select {
+	case <-f.synced:
+	case <-time.After(5 * time.Second):
+		f.t.Fatalf("timed out waiting for %s to be handled", eventType)
+	}
+}
+
+// interact dispatches an interaction by user in a channel and returns the
+// bot's response to it. Interactions in DM channels have no guild.
+func (f *fakeDiscord) interact(user *discordgo.User, channelID string, kind discordgo.InteractionType, data any) *fakeResponse {
+	f.t.Helper()
+	id := f.newID()
+	event := map[string]any{
+		"id":             id,
+		"application_id": testBotID,
+		"type":           kind,
+		"channel_id":     channelID,
+		"token":          "token-" + id,
+		"version":        1,
+		"data":           data,
+	}
+
+	f.mu.Lock()
+	_, dm := f.dmChannels[channelID]
+	f.mu.Unlock()
+	if dm {
+		event["user"] = user
+	} else {
+		event["guild_id"] = testGuildID
+		event["member"] = map[string]any{"user": user}
+	}
+	f.dispatch("INTERACTION_CREATE", event)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res, ok := f.responses[id]
+	if !ok {
+		f.t.Fatalf("interaction %v was not answered", data)
+	}
+	return res
+}
+
+// command runs a slash command.
+func (f *fakeDiscord) command(user *discordgo.User, channelID, name string) *fakeResponse {
+	f.t.Helper()
+	return f.commandOptions(user, channelID, name, nil)
+}
+
+// commandOptions runs a slash command with string, integer, boolean or user
+// options. A name like "event create" runs a subcommand.
+func (f *fakeDiscord) commandOptions(user *discordgo.User, channelID, name string, options map[string]any) *fakeResponse {
+	f.t.Helper()
+	opts := []map[string]any{}
+	for k, v := range options {
+		kind := discordgo.ApplicationCommandOptionString
+		switch v.(type) {
+		case int:
+			kind = discordgo.ApplicationCommandOptionInteger
+		case bool:
+			kind = discordgo.ApplicationCommandOptionBoolean
+		case *discordgo.User:
+			kind, v = discordgo.ApplicationCommandOptionUser, v.(*discordgo.User).ID
+		}
+		opts = append(opts, map[string]any{"name": k, "type": kind, "value": v})
+	}
+	command, sub, ok := strings.Cut(name, " ")
+	if ok {
+		opts = []map[string]any{{"name": sub, "type": discordgo.ApplicationCommandOptionSubCommand, "options": opts}}
+	}
+	return f.interact(user, channelID, discordgo.InteractionApplicationCommand, map[string]any{
+		"id":      "cmd-" + command,
+		"name":    command,
+		"type":    discordgo.ChatApplicationCommand,
+		"options": opts,
+	})
+}
+
+// click presses a button or, with values, picks options of a select menu.
+func (f *fakeDiscord) click(user *discordgo.User, channelID, customID string, values ...string) *fakeResponse {
+	f.t.Helper()
+	data := map[string]any{"custom_id": customID, "component_type": discordgo.ButtonComponent}
+	if values != nil {
+		data["component_type"] = discordgo.SelectMenuComponent
+		data["values"] = values
+	}
+	return f.interact(user, channelID, discordgo.InteractionMessageComponent, data)
+}
+
+// submit submits a modal with the given text input values.
+func (f *fakeDiscord) submit(user *discordgo.User, channelID, customID string, fields map[string]string) *fakeResponse {
+	f.t.Helper()
+	var rows []map[string]any
+	for id, value := range fields {
+		rows = append(rows, map[string]any{
+			"type":       discordgo.ActionsRowComponent,
+			"components": []map[string]any{{"type": discordgo.TextInputComponent, "custom_id": id, "value": value}},
+		})
+	}
+	return f.interact(user, channelID, discordgo.InteractionModalSubmit, map[string]any{"custom_id": customID, "components": rows})
+}
+
+// react adds a reaction of user to a message.
+func (f *fakeDiscord) react(userID, channelID, messageID, emoji string) {
+	f.t.Helper()
+	f.dispatch("MESSAGE_REACTION_ADD", map[string]any{
+		"user_id":    userID,
+		"channel_id": channelID,
+		"message_id": messageID,
+		"guild_id":   testGuildID,
+		"emoji":      map[string]any{"name": emoji},
+	})
+}
+
+// unreact removes a reaction of user from a message.
+func (f *fakeDiscord) unreact(userID, channelID, messageID, emoji string) {
+	f.t.Helper()
+	f.dispatch("MESSAGE_REACTION_REMOVE", map[string]any{
+		"user_id":    userID,
+		"channel_id": channelID,
+		"message_id": messageID,
+		"guild_id":   testGuildID,
+		"emoji":      map[string]any{"name": emoji},
+	})
+}
+
+// presence updates the presence of a user to playing the given games.
+func (f *fakeDiscord) presence(user *discordgo.User, games ...string) {
+	f.t.Helper()
+	activities := []map[string]any{}
+	for _, name := range games {
+		activities = append(activities, map[string]any{"name": name, "type": discordgo.ActivityTypeGame})
+	}
+	f.dispatch("PRESENCE_UPDATE", map[string]any{"guild_id": testGuildID, "user": map[string]any{"id": user.ID}, "status": "online", "activities": activities})
+}
+
+// join lets a user join the guild.
+func (f *fakeDiscord) join(user *discordgo.User, roles ...string) {
+	f.t.Helper()
+	f.dispatch("GUILD_MEMBER_ADD", map[string]any{"guild_id": testGuildID, "user": user, "roles": roles})
+}
+
+// REST API
+
+func (f *fakeDiscord) route(method, pattern string, handler func(w http.ResponseWriter, r *http.Request, params map[string]string)) {
+	f.routes = append(f.routes, fakeRoute{method: method, pattern: strings.Split(pattern, "/"), handler: handler})
+}
+
+func (f *fakeDiscord) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/gateway/ws") {
+		f.serveGateway(w, r)
+		return
+	}
+
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion+"/"), "/")
+	for _, route := range f.routes {
+		if params, ok := route.match(r.Method, path); ok {
+			route.handler(w, r, params)
+			return
+		}
+	}
+
+	f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	writeAPIError(w, http.StatusNotFound, "404: Not Found")
+}
+
+func (r fakeRoute) match(method string, path []string) (map[string]string, bool) {
+	if method != r.method || len(path) != len(r.pattern) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for n, s := range r.pattern {
+		if strings.HasPrefix(s, "{") {
+			params[strings.Trim(s, "{}")] = path[n]
+		} else if s != path[n] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (f *fakeDiscord) routeAPI() {
+	f.route("GET", "gateway", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		writeJSON(w, map[string]string{"url": "ws" + strings.TrimPrefix(f.server.URL, "http") + "/gateway/ws"})
+	})
+
+	f.route("GET", "guilds/{guild}/channels", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		writeJSON(w, f.channels)
+	})
+	f.route("GET", "guilds/{guild}/roles", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		writeJSON(w, f.roles)
+	})
+	f.route("GET", "guilds/{guild}/members/{user}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		writeJSON(w, &discordgo.Member{GuildID: p["guild"], User: &discordgo.User{ID: p["user"]}, Roles: f.rolesOf(p["user"])})
+	})
+	f.route("PUT", "guilds/{guild}/members/{user}/roles/{role}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		roles := f.memberRoles[p["user"]]
+		for _, id := range roles {
+			if id == p["role"] {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		f.memberRoles[p["user"]] = append(roles, p["role"])
+		f.send(0, "GUILD_MEMBER_UPDATE", map[string]any{"guild_id": testGuildID, "user": map[string]any{"id": p["user"]}, "roles": f.memberRoles[p["user"]]})
+		w.WriteHeader(http.StatusNoContent)
+	})
+	f.route("DELETE", "guilds/{guild}/members/{user}/roles/{role}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		roles := f.memberRoles[p["user"]][:0]
+		for _, id := range f.memberRoles[p["user"]] {
+			if id != p["role"] {
+				roles = append(roles, id)
+			}
+		}
+		f.memberRoles[p["user"]] = roles
+		f.send(0, "GUILD_MEMBER_UPDATE", map[string]any{"guild_id": testGuildID, "user": map[string]any{"id": p["user"]}, "roles": roles})
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	f.route("POST", "users/@me/channels", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		var body struct {
+			RecipientID string `json:"recipient_id"`
+		}
+		if !f.decode(w, r, &body) {
+			return
+		}
+		writeJSON(w, &discordgo.Channel{
+			ID:         f.dmChannelID(body.RecipientID),
+			Type:       discordgo.ChannelTypeDM,
+			Recipients: []*discordgo.User{{ID: body.RecipientID}},
+		})
+	})
+
+	f.route("GET", "channels/{channel}/messages", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			limit = 50
+		}
+		// Discord returns the newest messages first
+		messages := f.channelMessages(p["channel"])
+		newest := []*discordgo.Message{}
+		for n := len(messages) - 1; n >= 0 && len(newest) < limit; n-- {
+			newest = append(newest, messages[n])
+		}
+		writeJSON(w, newest)
+	})
+	f.route("GET", "channels/{channel}/messages/{message}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		if m := f.message(p["channel"], p["message"]); m != nil {
+			writeJSON(w, m)
+			return
+		}
+		writeUnknownMessage(w)
+	})
+	f.route("POST", "channels/{channel}/messages", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		m := &discordgo.Message{}
+		if !f.decode(w, r, m) {
+			return
+		}
+		if !checkContentLength(w, m.Content) {
+			return
+		}
+		writeJSON(w, f.postMessage(p["channel"], m))
+	})
+	f.route("PATCH", "channels/{channel}/messages/{message}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		var edit struct {
+			Content    *string                    `json:"content"`
+			Embeds     *[]*discordgo.MessageEmbed `json:"embeds"`
+			Components json.RawMessage            `json:"components"`
+		}
+		if !f.decode(w, r, &edit) {
+			return
+		}
+		if edit.Content != nil && !checkContentLength(w, *edit.Content) {
+			return
+		}
+		// Message decodes the component interfaces
+		var components discordgo.Message
+		if len(edit.Components) > 0 && string(edit.Components) != "null" {
+			if err := json.Unmarshal([]byte(`{"components":`+string(edit.Components)+`}`), &components); err != nil {
+				writeAPIError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, m := range f.messages[p["channel"]] {
+			if m.ID == p["message"] {
+				if edit.Content != nil {
+					m.Content = *edit.Content
+				}
+				if edit.Embeds != nil {
+					m.Embeds = *edit.Embeds
+				}
+				if components.Components != nil {
+					m.Components = components.Components
+				}
+				writeJSON(w, m)
+				return
+			}
+		}
+		writeUnknownMessage(w)
+	})
+	f.route("DELETE", "channels/{channel}/messages/{message}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		messages := f.messages[p["channel"]]
+		for n, m := range messages {
+			if m.ID == p["message"] {
+				f.messages[p["channel"]] = append(messages[:n:n], messages[n+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeUnknownMessage(w)
+	})
+	f.route("GET", "channels/{channel}/pins", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		pinned := []*discordgo.Message{}
+		for _, m := range f.channelMessages(p["channel"]) {
+			if m.Pinned {
+				pinned = append(pinned, m)
+			}
+		}
+		writeJSON(w, pinned)
+	})
+	f.route("PUT", "channels/{channel}/pins/{message}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, m := range f.messages[p["channel"]] {
+			if m.ID == p["message"] {
+				m.Pinned = true
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeUnknownMessage(w)
+	})
+	f.route("PUT", "channels/{channel}/messages/{message}/reactions/{emoji}/@me", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.reactions[p["message"]] = append(f.reactions[p["message"]], p["emoji"])
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	f.route("PUT", "applications/{application}/guilds/{guild}/commands", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		var commands []*discordgo.ApplicationCommand
+		if !f.decode(w, r, &commands) {
+			return
+		}
+		for _, c := range commands {
+			c.ID = f.newID()
+			c.ApplicationID = p["application"]
+			c.GuildID = p["guild"]
+		}
+
+		f.mu.Lock()
+		f.commands = commands
+		f.mu.Unlock()
+		writeJSON(w, commands)
+	})
+
+	f.route("POST", "interactions/{interaction}/{token}/callback", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		var body struct {
+			Type discordgo.InteractionResponseType `json:"type"`
+			Data json.RawMessage                   `json:"data"`
+		}
+		if !f.decode(w, r, &body) {
+			return
+		}
+		res := &fakeResponse{Type: body.Type, Message: &discordgo.Message{}}
+		if len(body.Data) > 0 {
+			var modal struct {
+				CustomID string `json:"custom_id"`
+				Title    string `json:"title"`
+			}
+			if err := json.Unmarshal(body.Data, res.Message); err != nil {
+				f.t.Errorf("decoding interaction response: %v", err)
+			}
+			if err := json.Unmarshal(body.Data, &modal); err != nil {
+				f.t.Errorf("decoding interaction response: %v", err)
+			}
+			res.CustomID, res.Title = modal.CustomID, modal.Title
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.responses[p["interaction"]]; ok {
+			writeAPIError(w, http.StatusBadRequest, "Interaction has already been acknowledged.")
+			return
+		}
+		f.responses[p["interaction"]] = res
+		w.WriteHeader(http.StatusNoContent)
+	})
+	f.route("POST", "webhooks/{application}/{token}", func(w http.ResponseWriter, r *http.Request, p map[string]string) {
+		m := &discordgo.Message{}
+		if !f.decode(w, r, m) {
+			return
+		}
+		m.ID = f.newID()
+		m.WebhookID = p["application"]
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := strings.TrimPrefix(p["token"], "token-")
+		res, ok := f.responses[id]
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, "Unknown Webhook")
+			return
+		}
+		res.Followups = append(res.Followups, m)
+		writeJSON(w, m)
+	})
+}
+
+func (f *fakeDiscord) message(channelID, messageID string) *discordgo.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.messages[channelID] {
+		if m.ID == messageID {
+			return m
+		}
+	}
+	return nil
+}
+
+// checkContentLength rejects message content over Discord's limit.
+func checkContentLength(w http.ResponseWriter, content string) bool {
+	if utf8.RuneCountInString(content) > 2000 {
+		writeAPIError(w, http.StatusBadRequest, "content: Must be 2000 or fewer in length.")
+		return false
+	}
+	return true
+}
+
+// postMessage adds a message of the bot to a channel.
+func (f *fakeDiscord) postMessage(channelID string, m *discordgo.Message) *discordgo.Message {
+	m.ID = f.newID()
+	m.ChannelID = channelID
+	m.GuildID = testGuildID
+	m.Author = &discordgo.User{ID: testBotID, Bot: true}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[channelID] = append(f.messages[channelID], m)
+	return m
+}
+
+func (f *fakeDiscord) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		f.t.Errorf("decoding %s %s: %v", r.Method, r.URL.Path, err)
+		writeAPIError(w, http.StatusBadRequest, "400: Bad Request")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeUnknownMessage(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]any{"code": discordgo.ErrCodeUnknownMessage, "message": "Unknown Message"})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"code": 0, "message": message})
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// eventReminders are how long before the start attendees are reminded of an
+// event, longest first.
+var eventReminders = []time.Duration{30 * time.Minute, 5 * time.Minute}
+
+// eventTimeLayouts are accepted for the start of an event. Times without an
+// offset are in UTC.
+var eventTimeLayouts = []string{
+	"2006-01-02 15:04 -07:00",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04",
+	time.RFC3339,
+}
+
+func parseEventTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "UTC"))
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid event time %q", s)
+}
+
+func timestamp(t time.Time, style string) string {
+	return "<t:" + strconv.FormatInt(t.Unix(), 10) + ":" + style + ">"
+}
+
+func mentions(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	var list []string
+	for _, id := range ids {
+		list = append(list, "<@"+id+">")
+	}
+	return strings.Join(list, "\n")
+}
+
+func (b *Bot) eventEmbed(e *Event) *discordgo.MessageEmbed {
+	going := fmt.Sprintf("Going (%d):", len(e.Attendees))
+	if e.Slots > 0 {
+		going = fmt.Sprintf("Going (%d/%d):", len(e.Attendees), e.Slots)
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Start:", Value: timestamp(e.Start, "F") + " (" + timestamp(e.Start, "R") + ")"},
+		{Name: "Host:", Value: "<@" + e.Host + ">", Inline: true},
+	}
+	if e.Platform != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Platform:", Value: b.Config.platformLabel(e.Platform), Inline: true})
+	}
+	fields = append(fields,
+		&discordgo.MessageEmbedField{Name: going, Value: mentions(e.Attendees)},
+		&discordgo.MessageEmbedField{Name: fmt.Sprintf("Maybe (%d):", len(e.Maybe)), Value: mentions(e.Maybe)},
+	)
+
+	return &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		Color:       colorBlurple,
+		Title:       e.Title,
+		Description: e.Activity,
+		Fields:      fields,
+	}
+}
+
+func eventButtons(e *Event) []discordgo.MessageComponent {
+	id := e.ID.Hex()
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: customID("event", id, string(RSVPJoin))},
+				discordgo.Button{Label: "Maybe", Style: discordgo.SecondaryButton, CustomID: customID("event", id, string(RSVPMaybe))},
+				discordgo.Button{Label: "Leave", Style: discordgo.DangerButton, CustomID: customID("event", id, string(RSVPLeave))},
+			},
+		},
+	}
+}
+
+// createEvent posts a new event in the channel the command was used in. The
+// host is the first attendee.
+func (b *Bot) createEvent(g *guild, i *interaction) error {
+	start, err := parseEventTime(i.option("start"))
+	if err != nil || !start.After(time.Now()) {
+		b.respondEphemeral(i, "Please enter a start in the future like `2023-05-01 20:00 +02:00`. Times without an offset are in UTC.")
+		return nil
+	}
+
+	platform, _ := g.platform(i.ChannelID)
+	if name := i.option("platform"); name != "" {
+		var ok bool
+		if platform, ok = b.Config.findPlatform(name); !ok {
+			var labels []string
+			for _, p := range b.Config.Platforms {
+				labels = append(labels, p.Label)
+			}
+			b.respondEphemeral(i, "Unknown platform, please choose one of "+strings.Join(labels, ", ")+".")
+			return nil
+		}
+	}
+	slots, _ := i.intOption("slots")
+
+	e := &Event{
+		ID:        primitive.NewObjectID(),
+		GuildID:   g.GuildID,
+		ChannelID: i.ChannelID,
+		Host:      i.Member.User.ID,
+		Title:     strings.TrimSpace(i.option("title")),
+		Activity:  strings.TrimSpace(i.option("activity")),
+		Platform:  platform,
+		Start:     start,
+		Slots:     int(slots),
+		Attendees: []string{i.Member.User.ID},
+	}
+
+	message, err := b.Session.ChannelMessageSendComplex(i.ChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{b.eventEmbed(e)},
+		Components: eventButtons(e),
+	})
+	if err != nil {
+		return err
+	}
+	e.MessageID = message.ID
+
+	err = b.Events.CreateEvent(context.TODO(), e)
+	if err != nil {
+		// Without the stored event the buttons would not work
+		if err := b.Session.ChannelMessageDelete(i.ChannelID, message.ID); err != nil {
+			b.reportError(i.log, "Error deleting message", err)
+		}
+		return err
+	}
+
+	i.log.Info("Event created", "event_id", e.ID.Hex(), "start", e.Start)
+	b.respondEphemeral(i, "Your event has been posted. You will be reminded before it starts.")
+	return nil
+}
+
+// respondEvent records the button pressed on an event and updates its attendee list.
+func (b *Bot) respondEvent(i *interaction) error {
+	id, err := primitive.ObjectIDFromHex(i.param("eventID"))
+	if err != nil {
+		return fmt.Errorf("invalid event ID: %w", err)
+	}
+	rsvp := RSVP(i.param("rsvp"))
+	if rsvp != RSVPJoin && rsvp != RSVPMaybe && rsvp != RSVPLeave {
+		return fmt.Errorf("unknown RSVP %q", rsvp)
+	}
+
+	e, err := b.Events.Event(context.TODO(), id)
+	if err == ErrEventNotFound {
+		b.respondEphemeral(i, "This event no longer exists.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !e.Start.After(time.Now()) {
+		b.respondEphemeral(i, "This event has already started.")
+		return nil
+	}
+
+	e, err = b.Events.RespondEvent(context.TODO(), id, i.userID(), rsvp)
+	if errors.Is(err, ErrEventFull) {
+		b.respondEphemeral(i, "Sorry, all slots of this event are taken. You can still answer with Maybe.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{b.eventEmbed(e)},
+			Components: eventButtons(e),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+// runEventReminders periodically reminds attendees of upcoming events until
+// ctx is cancelled. Reminders are stored with the events, so none are sent
+// twice across restarts.
+func (b *Bot) runEventReminders(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.remindEvents(ctx, now)
+		}
+	}
+}
+
+// remindEvents sends the reminders that are due by now. After a downtime only
+// the latest due reminder is sent.
+func (b *Bot) remindEvents(ctx context.Context, now time.Time) {
+	events, err := b.Events.UpcomingEvents(ctx, now, now.Add(eventReminders[0]))
+	if err != nil {
+		b.reportError(slog.Default(), "Error reading upcoming events", err)
+		return
+	}
+
+events:
+	for n := range events {
+		e := &events[n]
+		log := slog.With("guild_id", e.GuildID, "event_id", e.ID.Hex())
+
+		var due []time.Duration
+		for _, lead := range eventReminders {
+			if !now.Before(e.Start.Add(-lead)) && !remindedOf(e, lead) {
+				due = append(due, lead)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		for _, lead := range due {
+			if err := b.Events.SetEventReminded(ctx, e.ID, lead); err != nil {
+				b.reportError(log, "Error updating event", err)
+				continue events
+			}
+		}
+		b.remindAttendees(e, log)
+	}
+}
+
+func remindedOf(e *Event, lead time.Duration) bool {
+	for _, d := range e.Reminded {
+		if d == lead {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) remindAttendees(e *Event, log *slog.Logger) {
+	content := "⏰ **" + e.Title + "** starts " + timestamp(e.Start, "R")
+	if e.Platform != "" {
+		content += " on " + b.Config.platformLabel(e.Platform)
+	}
+	content += ".\nhttps://discord.com/channels/" + e.GuildID + "/" + e.ChannelID + "/" + e.MessageID
+
+	log.Info("Reminding attendees of event", "attendees", len(e.Attendees))
+	for _, id := range e.Attendees {
+		channel, err := b.Session.UserChannelCreate(id)
+		if err == nil {
+			_, err = b.Session.ChannelMessageSend(channel.ID, content)
+		}
+		if err != nil {
+			// Players may not accept DMs, which is not worth reporting
+			log.Warn("Could not send DM", "user_id", id, "err", err)
+		}
+	}
+}
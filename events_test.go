@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseEventTime(t *testing.T) {
+	want := time.Date(2023, 5, 1, 18, 0, 0, 0, time.UTC)
+	for _, s := range []string{"2023-05-01 18:00", "2023-05-01 18:00 UTC", " 2023-05-01 20:00 +02:00", "2023-05-01 20:00 +0200", "2023-05-01T20:00:00+02:00"} {
+		got, err := parseEventTime(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseEventTime(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "tomorrow", "2023-05-01", "01.05.2023 20:00"} {
+		if _, err := parseEventTime(s); err == nil {
+			t.Errorf("parseEventTime(%q) did not fail", s)
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	sadie := &discordgo.User{ID: "302", Username: "sadie"}
+
+	res := f.commandOptions(testUser, general, "event create", map[string]any{"title": "Posse up", "start": "2023-05-01 20:00"})
+	if !strings.HasPrefix(res.Message.Content, "Please enter a start in the future") {
+		t.Errorf("event in the past = %q", res.Message.Content)
+	}
+
+	start := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Minute)
+	res = f.commandOptions(testUser, general, "event create", map[string]any{
+		"title":    "Legendary bounties",
+		"start":    start.Format("2006-01-02 15:04"),
+		"activity": "Etta Doyle",
+		"platform": "playstation",
+		"slots":    2,
+	})
+	if res.Message.Flags != discordgo.MessageFlagsEphemeral || !strings.HasPrefix(res.Message.Content, "Your event has been posted") {
+		t.Fatalf("/event create = %+v", res.Message)
+	}
+	messages := f.channelMessages(general)
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages in general, want the event", len(messages))
+	}
+	fields := eventFields(messages[0].Embeds[0])
+	if fields["Going (1/2):"] != "<@"+testUser.ID+">" || fields["Platform:"] != "Playstation" || !strings.Contains(fields["Start:"], timestamp(start, "F")) {
+		t.Errorf("event fields = %v", fields)
+	}
+
+	button := func(user *discordgo.User, rsvp RSVP) *fakeResponse {
+		t.Helper()
+		row := messages[0].Components[0].(*discordgo.ActionsRow)
+		for _, c := range row.Components {
+			if id := c.(*discordgo.Button).CustomID; strings.HasSuffix(id, ":"+string(rsvp)) {
+				return f.click(user, general, id)
+			}
+		}
+		t.Fatalf("no %s button", rsvp)
+		return nil
+	}
+
+	res = button(john, RSVPJoin)
+	if res.Type != discordgo.InteractionResponseUpdateMessage || eventFields(res.Message.Embeds[0])["Going (2/2):"] != "<@"+testUser.ID+">\n<@"+john.ID+">" {
+		t.Errorf("after joining = %v", eventFields(res.Message.Embeds[0]))
+	}
+	res = button(sadie, RSVPJoin)
+	if !strings.HasPrefix(res.Message.Content, "Sorry, all slots") {
+		t.Errorf("joining a full event = %+v", res.Message)
+	}
+	res = button(sadie, RSVPMaybe)
+	if eventFields(res.Message.Embeds[0])["Maybe (1):"] != "<@"+sadie.ID+">" {
+		t.Errorf("after maybe = %v", eventFields(res.Message.Embeds[0]))
+	}
+	button(john, RSVPLeave)
+	res = button(sadie, RSVPJoin)
+	fields = eventFields(res.Message.Embeds[0])
+	if fields["Going (2/2):"] != "<@"+testUser.ID+">\n<@"+sadie.ID+">" || fields["Maybe (0):"] != "-" {
+		t.Errorf("after leaving and joining = %v", fields)
+	}
+
+	ctx := context.Background()
+	reminders := func() (n int) {
+		for _, user := range []*discordgo.User{testUser, john, sadie} {
+			n += len(f.channelMessages(f.dmChannelID(user.ID)))
+		}
+		return n
+	}
+	b.remindEvents(ctx, start.Add(-45*time.Minute))
+	if n := reminders(); n != 0 {
+		t.Fatalf("got %d reminders 45 minutes before the start", n)
+	}
+	b.remindEvents(ctx, start.Add(-29*time.Minute))
+	b.remindEvents(ctx, start.Add(-28*time.Minute))
+	if n := reminders(); n != 2 {
+		t.Fatalf("got %d reminders 30 minutes before the start, want one per attendee", n)
+	}
+	if dm := f.channelMessages(f.dmChannelID(sadie.ID)); !strings.Contains(dm[0].Content, "**Legendary bounties** starts") {
+		t.Errorf("reminder = %q", dm[0].Content)
+	}
+	b.remindEvents(ctx, start.Add(-4*time.Minute))
+	if n := reminders(); n != 4 {
+		t.Errorf("got %d reminders 5 minutes before the start, want two per attendee", n)
+	}
+}
+
+func TestEventReminderErrors(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+	john := &discordgo.User{ID: "301", Username: "john"}
+
+	start := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Minute)
+	for _, user := range []*discordgo.User{testUser, john} {
+		f.commandOptions(user, general, "event create", map[string]any{"title": "Posse up", "start": start.Format("2006-01-02 15:04")})
+	}
+	ctx := context.Background()
+	events, err := b.Events.UpcomingEvents(ctx, time.Now(), start)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("upcoming events = %+v, %v", events, err)
+	}
+
+	// An event failing to update does not hold up the reminders of the others
+	b.Events = failingEventStore{EventStore: b.Events, failing: events[0].ID}
+	b.remindEvents(ctx, start.Add(-29*time.Minute))
+	b.ErrorReport.Flush()
+	if reported := f.takeReportedErrors(); len(reported) != 1 {
+		t.Errorf("reported errors = %q, want 1", reported)
+	}
+	for n, e := range events {
+		if got := len(f.channelMessages(f.dmChannelID(e.Host))); got != n {
+			t.Errorf("host of event %d got %d reminders, want %d", n, got, n)
+		}
+	}
+}
+
+// failingEventStore fails to record reminders of one event.
+type failingEventStore struct {
+	EventStore
+	failing primitive.ObjectID
+}
+
+func (s failingEventStore) SetEventReminded(ctx context.Context, id primitive.ObjectID, lead time.Duration) error {
+	if id == s.failing {
+		return errors.New("database down")
+	}
+	return s.EventStore.SetEventReminded(ctx, id, lead)
+}
+
+func eventFields(embed *discordgo.MessageEmbed) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range embed.Fields {
+		fields[field.Name] = field.Value
+	}
+	return fields
+}
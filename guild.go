@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var errGuildNotReady = errors.New("server is not set up")
+
+// guild holds the state of a server the bot has been set up on.
+type guild struct {
+	GuildConfig
+	roles      map[string]*serverRole
+	commandIDs map[string]string
+	log        *slog.Logger
+}
+
+// guild returns the server with the given ID or nil if it is not set up (yet).
+func (b *Bot) guild(guildID string) *guild {
+	b.guildsMu.RLock()
+	defer b.guildsMu.RUnlock()
+	return b.guilds[guildID]
+}
+
+// claimGuild reports whether the caller should set up the given server. It
+// returns false if the server is already set up or in the process of it.
+func (b *Bot) claimGuild(guildID string) bool {
+	b.guildsMu.Lock()
+	defer b.guildsMu.Unlock()
+	if _, ok := b.guilds[guildID]; ok {
+		return false
+	}
+	b.guilds[guildID] = nil
+	return true
+}
+
+func (b *Bot) storeGuild(g *guild) {
+	b.guildsMu.Lock()
+	defer b.guildsMu.Unlock()
+	b.guilds[g.GuildID] = g
+}
+
+// readyGuilds returns the servers that are set up.
+func (b *Bot) readyGuilds() []*guild {
+	b.guildsMu.RLock()
+	defer b.guildsMu.RUnlock()
+
+	var guilds []*guild
+	for _, g := range b.guilds {
+		if g != nil {
+			guilds = append(guilds, g)
+		}
+	}
+	return guilds
+}
+
+// namespaces returns the player namespaces of all set up servers.
+func (b *Bot) namespaces() []string {
+	b.guildsMu.RLock()
+	defer b.guildsMu.RUnlock()
+
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, g := range b.guilds {
+		if g != nil && !seen[g.Namespace] {
+			seen[g.Namespace] = true
+			namespaces = append(namespaces, g.Namespace)
+		}
+	}
+	return namespaces
+}
+
+func (b *Bot) guildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	b.prepareGuild(g.ID)
+}
+
+func (g *guild) player(discordID string) PlayerKey {
+	return PlayerKey{Namespace: g.Namespace, DiscordID: discordID}
+}
+
+// platform returns the platform whose channel has the given ID.
+func (g *guild) platform(channelID string) (string, bool) {
+	for platform, id := range g.Channels.Platforms {
+		if id == channelID {
+			return platform, true
+		}
+	}
+	return "", false
+}
+
+// commandMention returns the clickable mention of a registered command or
+// subcommand like "event create".
+func (g *guild) commandMention(name string) string {
+	command, _, _ := strings.Cut(name, " ")
+	return "</" + name + ":" + g.commandIDs[command] + ">"
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var (
+	errGatewayNotConnected = errors.New("not connected")
+	errSetupNotCompleted   = errors.New("not completed")
+)
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (b *Bot) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", b.healthz)
+	mux.HandleFunc("/healthz", b.healthz)
+	mux.HandleFunc("/readyz", b.readyz)
+	mux.Handle("/metrics", b.metrics.handler())
+	return mux
+}
+
+// healthz reports that the process is alive and serving requests.
+func (b *Bot) healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: "OK"})
+}
+
+// readyz reports whether the bot is connected to Discord and the database and
+// has set up its servers.
+func (b *Bot) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	status := http.StatusOK
+	resp := healthResponse{Status: "OK", Checks: make(map[string]string)}
+	check := func(name string, err error) {
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			resp.Status = "UNAVAILABLE"
+			resp.Checks[name] = err.Error()
+			return
+		}
+		resp.Checks[name] = "OK"
+	}
+
+	b.Session.RLock()
+	gatewayReady := b.Session.DataReady
+	b.Session.RUnlock()
+	if gatewayReady {
+		check("gateway", nil)
+	} else {
+		check("gateway", errGatewayNotConnected)
+	}
+
+	check("database", b.Players.Ping(ctx))
+
+	if b.prepared.Load() {
+		check("setup", nil)
+	} else {
+		check("setup", errSetupNotCompleted)
+	}
+
+	writeHealth(w, status, resp)
+}
+
+func writeHealth(w http.ResponseWriter, status int, resp healthResponse) {
+	jsonRes, err := json.Marshal(resp)
+	if err != nil {
+		logError(slog.Default(), 0, "Error happened in JSON marshal", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonRes)
+}
@@ -1,64 +1,243 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/airbrake/gobrake/v5"
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type Env struct {
-	environment, botToken, botRole, guildID, changelogURL, airbrakeKey, mongodbCreds, dbName, collName string
-	airbrakeID                                                                                         int64
+	environment, botToken, botRole, guildID, changelogURL, airbrakeKey, dbName, collName, store, configFile string
+	mongodbURI, mongodbCreds, mongodbUser, mongodbPassword, mongodbAuthSource, mongodbAuthMechanism         string
+	mongodbTLSCAFile, mongodbTLSCertFile, avatarBaseURL                                                     string
+	autoMigrate, detectPresence, checkAvatars                                                               bool
+	mongodbConnectTimeout                                                                                   time.Duration
+	airbrakeID                                                                                              int64
 }
 
+// legacyMongodbURI is the cluster used before MONGODB_URI was introduced.
+const legacyMongodbURI = "mongodb+srv://%s@cluster0.w5ind.mongodb.net/?retryWrites=true&w=majority"
+
 func readEnv() *Env {
-	fmt.Println("================================================================================")
-	log.Println("Reading environment variables...")
+	environment := "PRODUCTION"
+	guildIDKey := "GUILD_ID"
+	getenv := os.Getenv
+
 	if _, err := os.Stat(".env"); err == nil {
 		envs, err := godotenv.Read(".env")
 		if err != nil {
-			log.Fatal("Error loading .env file")
+			fatal("Error loading .env file", err)
 		}
 
-		developmentEnvironment := Env{environment: "DEVELOPMENT", botToken: envs["BOT_TOKEN"], botRole: envs["BOT_ROLE"], guildID: envs["DEV_GUILD_ID"], changelogURL: envs["CHANGELOG"], airbrakeKey: envs["AIRBRAKE_KEY"], mongodbCreds: envs["MONGODB_CREDS"], dbName: envs["DB"], collName: "players"}
+		environment = "DEVELOPMENT"
+		guildIDKey = "DEV_GUILD_ID"
+		getenv = func(key string) string { return envs[key] }
+	}
 
-		airbrakeIDString := envs["AIRBRAKE_ID"]
-		airbrakeIDToInt, _ := strconv.Atoi(airbrakeIDString)
-		developmentEnvironment.airbrakeID = int64(airbrakeIDToInt)
+	// Everything after this is logged with the configured level
+	initializeLogger(getenv("LOG_LEVEL"))
+	slog.Info("Reading environment variables...")
 
-		return &developmentEnvironment
-	} else {
-		productionEnvironment := Env{environment: "PRODUCTION", botToken: os.Getenv("BOT_TOKEN"), botRole: os.Getenv("BOT_ROLE"), guildID: os.Getenv("GUILD_ID"), changelogURL: os.Getenv("CHANGELOG"), airbrakeKey: os.Getenv("AIRBRAKE_KEY"), mongodbCreds: os.Getenv("MONGODB_CREDS"), dbName: os.Getenv("DB"), collName: "players"}
+	e := Env{
+		environment:  environment,
+		botToken:     getenv("BOT_TOKEN"),
+		botRole:      getenv("BOT_ROLE"),
+		guildID:      getenv(guildIDKey),
+		changelogURL: getenv("CHANGELOG"),
+		airbrakeKey:  getenv("AIRBRAKE_KEY"),
+		dbName:       getenv("DB"),
+		collName:     getenv("MONGODB_COLLECTION"),
+		store:        getenv("STORE"),
+		configFile:   getenv("CONFIG_FILE"),
 
-		airbrakeIDToInt, _ := strconv.Atoi(os.Getenv("AIRBRAKE_ID"))
-		productionEnvironment.airbrakeID = int64(airbrakeIDToInt)
+		mongodbURI:           getenv("MONGODB_URI"),
+		mongodbCreds:         getenv("MONGODB_CREDS"),
+		mongodbUser:          getenv("MONGODB_USERNAME"),
+		mongodbPassword:      getenv("MONGODB_PASSWORD"),
+		mongodbAuthSource:    getenv("MONGODB_AUTH_SOURCE"),
+		mongodbAuthMechanism: getenv("MONGODB_AUTH_MECHANISM"),
+		mongodbTLSCAFile:     getenv("MONGODB_TLS_CA_FILE"),
+		mongodbTLSCertFile:   getenv("MONGODB_TLS_CERT_FILE"),
+	}
 
-		return &productionEnvironment
+	airbrakeIDToInt, _ := strconv.Atoi(getenv("AIRBRAKE_ID"))
+	e.airbrakeID = int64(airbrakeIDToInt)
+
+	if e.configFile == "" {
+		e.configFile = "config.yaml"
+	}
+	e.autoMigrate = getenv("AUTO_MIGRATE") != "false"
+	e.detectPresence = getenv("PRESENCE_DETECTION") == "true"
+	e.avatarBaseURL = getenv("AVATAR_BASE_URL")
+	if e.avatarBaseURL == "" {
+		e.avatarBaseURL = rdoAvatarURLPrefix
+	}
+	e.checkAvatars = getenv("AVATAR_CHECK") == "true"
+	if e.collName == "" {
+		e.collName = "players"
 	}
+
+	e.mongodbConnectTimeout = 2 * time.Minute
+	if timeout := getenv("MONGODB_CONNECT_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			fatal("Invalid MONGODB_CONNECT_TIMEOUT", err)
+		}
+		e.mongodbConnectTimeout = d
+	}
+
+	return &e
 }
 
 func initializeBot(e *Env) *discordgo.Session {
-	log.Printf("Starting bot in %s mode...", e.environment)
+	slog.Info("Starting bot", "mode", e.environment)
 
 	s, err := discordgo.New("Bot " + e.botToken)
 	if err != nil {
-		log.Fatalf("Invalid bot parameters: %v", err)
+		fatal("Invalid bot parameters", err)
 	}
 
 	return s
 }
 
 func initializeErrorReport(e *Env) *gobrake.Notifier {
-	log.Println("Register error logging...")
+	slog.Info("Register error logging...")
 	return gobrake.NewNotifierWithOptions(&gobrake.NotifierOptions{
 		ProjectId:   e.airbrakeID,
 		ProjectKey:  e.airbrakeKey,
 		Environment: strings.ToLower(e.environment),
 	})
 }
+
+func initializeDatabase(e *Env, errorReport *gobrake.Notifier) *mongo.Client {
+	slog.Info("Connecting to database...")
+	clientOptions, err := databaseOptions(e)
+	if err != nil {
+		fatal("Invalid database settings", err)
+	}
+
+	client, err := connectDatabase(clientOptions, e.mongodbConnectTimeout)
+	if err != nil {
+		errorReport.Notify(err, nil)
+		fatal("Error connecting to database", err)
+	}
+
+	return client
+}
+
+// databaseOptions builds the client options from MONGODB_URI. Credentials and
+// TLS files set separately take precedence over the ones in the URI.
+func databaseOptions(e *Env) (*options.ClientOptions, error) {
+	uri := e.mongodbURI
+	clientOptions := options.Client()
+	if uri == "" {
+		if e.mongodbCreds == "" {
+			return nil, errors.New("MONGODB_URI is not set")
+		}
+		slog.Warn("MONGODB_CREDS is deprecated, please set MONGODB_URI instead")
+		uri = fmt.Sprintf(legacyMongodbURI, e.mongodbCreds)
+		clientOptions.SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1))
+	}
+	clientOptions.ApplyURI(uri)
+
+	if e.mongodbUser != "" || e.mongodbAuthSource != "" || e.mongodbAuthMechanism != "" {
+		var credential options.Credential
+		if clientOptions.Auth != nil {
+			credential = *clientOptions.Auth
+		}
+		if e.mongodbUser != "" {
+			credential.Username = e.mongodbUser
+			credential.Password = e.mongodbPassword
+			credential.PasswordSet = e.mongodbPassword != ""
+		}
+		if e.mongodbAuthSource != "" {
+			credential.AuthSource = e.mongodbAuthSource
+		}
+		if e.mongodbAuthMechanism != "" {
+			credential.AuthMechanism = e.mongodbAuthMechanism
+		}
+		clientOptions.SetAuth(credential)
+	}
+
+	if e.mongodbTLSCAFile != "" || e.mongodbTLSCertFile != "" {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if clientOptions.TLSConfig != nil {
+			config = clientOptions.TLSConfig.Clone()
+		}
+
+		if e.mongodbTLSCAFile != "" {
+			ca, err := os.ReadFile(e.mongodbTLSCAFile)
+			if err != nil {
+				return nil, err
+			}
+			config.RootCAs = x509.NewCertPool()
+			if !config.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificates found in %s", e.mongodbTLSCAFile)
+			}
+		}
+
+		// The client certificate and its key are expected in the same file
+		if e.mongodbTLSCertFile != "" {
+			cert, err := tls.LoadX509KeyPair(e.mongodbTLSCertFile, e.mongodbTLSCertFile)
+			if err != nil {
+				return nil, err
+			}
+			config.Certificates = []tls.Certificate{cert}
+		}
+		clientOptions.SetTLSConfig(config)
+	}
+
+	return clientOptions, clientOptions.Validate()
+}
+
+// connectDatabase pings the database until it answers, waiting longer after
+// every failed attempt. It gives up once the next attempt would start after
+// timeout.
+func connectDatabase(clientOptions *options.ClientOptions, timeout time.Duration) (*mongo.Client, error) {
+	client, err := mongo.Connect(context.Background(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = client.Ping(ctx, readpref.Primary())
+		cancel()
+		if err == nil {
+			return client, nil
+		}
+
+		delay := backoff(attempt)
+		if time.Now().Add(delay).After(deadline) {
+			_ = client.Disconnect(context.Background())
+			return nil, fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("Database not reachable, retrying...", "attempt", attempt, "retry_in", delay, "err", err)
+		time.Sleep(delay)
+	}
+}
+
+// backoff returns the delay before the next attempt, doubling from one second
+// up to 30 seconds.
+func backoff(attempt int) time.Duration {
+	const maxDelay = 30 * time.Second
+	if attempt > 5 {
+		return maxDelay
+	}
+	return min(time.Second<<(attempt-1), maxDelay)
+}
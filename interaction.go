@@ -0,0 +1,123 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interaction is an incoming interaction together with a logger carrying its
+// identifying attributes and the data extracted by the router.
+type interaction struct {
+	*discordgo.InteractionCreate
+	kind     string
+	customID string
+	name     string
+	params   map[string]string
+	fields   modalFields
+	log      *slog.Logger
+}
+
+func newInteraction(ic *discordgo.InteractionCreate) *interaction {
+	i := &interaction{InteractionCreate: ic}
+
+	switch ic.Type {
+	case discordgo.InteractionApplicationCommand:
+		// Subcommands are routed like "event create"
+		i.kind, i.customID = "command", ic.ApplicationCommandData().Name
+		if sub := i.subcommand(); sub != nil {
+			i.customID += " " + sub.Name
+		}
+	case discordgo.InteractionMessageComponent:
+		i.kind, i.customID = "component", ic.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		i.kind, i.customID = "modal", ic.ModalSubmitData().CustomID
+	default:
+		i.kind = "other"
+	}
+
+	i.log = slog.With(
+		"interaction_id", ic.ID,
+		"guild_id", ic.GuildID,
+		"channel_id", ic.ChannelID,
+		"user_id", i.userID(),
+		"type", i.kind,
+		"custom_id", i.customID,
+	)
+	return i
+}
+
+// userID returns the ID of the user who triggered the interaction, both in
+// servers and in DMs.
+func (i *interaction) userID() string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// subcommand returns the subcommand that was run or nil for plain commands.
+func (i *interaction) subcommand() *discordgo.ApplicationCommandInteractionDataOption {
+	options := i.ApplicationCommandData().Options
+	if len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		return options[0]
+	}
+	return nil
+}
+
+// options returns the options of the command or of its subcommand.
+func (i *interaction) options() []*discordgo.ApplicationCommandInteractionDataOption {
+	if sub := i.subcommand(); sub != nil {
+		return sub.Options
+	}
+	return i.ApplicationCommandData().Options
+}
+
+// option returns the value of a string option of a command or "" if it is not set.
+func (i *interaction) option(name string) string {
+	for _, o := range i.options() {
+		if o.Name == name && o.Type == discordgo.ApplicationCommandOptionString {
+			return o.StringValue()
+		}
+	}
+	return ""
+}
+
+// intOption returns the value of an integer option of a command and whether it is set.
+func (i *interaction) intOption(name string) (int64, bool) {
+	for _, o := range i.options() {
+		if o.Name == name && o.Type == discordgo.ApplicationCommandOptionInteger {
+			return o.IntValue(), true
+		}
+	}
+	return 0, false
+}
+
+// boolOption returns the value of a boolean option of a command and whether it is set.
+func (i *interaction) boolOption(name string) (bool, bool) {
+	for _, o := range i.options() {
+		if o.Name == name && o.Type == discordgo.ApplicationCommandOptionBoolean {
+			return o.BoolValue(), true
+		}
+	}
+	return false, false
+}
+
+// userOption returns the ID of the user picked for an option or "" if it is not set.
+func (i *interaction) userOption(name string) string {
+	for _, o := range i.options() {
+		if o.Name == name && o.Type == discordgo.ApplicationCommandOptionUser {
+			id, _ := o.Value.(string)
+			return id
+		}
+	}
+	return ""
+}
+
+// param returns the value of a route parameter.
+func (i *interaction) param(name string) string {
+	return i.params[name]
+}
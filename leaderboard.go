@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// leaderboardPageSize is the number of players listed per page.
+	leaderboardPageSize = 10
+	// rankingTTL is how long a ranking is reused when turning pages.
+	rankingTTL = time.Minute
+)
+
+// leaderboardCategory ranks players by a value computed from their sessions.
+type leaderboardCategory struct {
+	Name  string
+	Label string
+	score func(sessions []Session, since time.Time) float64
+	// format displays a score
+	format func(score float64) string
+	// privacy returns the visibility of the score, nil for scores that are
+	// always shown
+	privacy func(p Privacy) Visibility
+}
+
+var leaderboardCategories = []leaderboardCategory{
+	{
+		Name:  "playtime",
+		Label: "Playtime",
+		score: func(sessions []Session, since time.Time) float64 {
+			return float64(summarizeSessions(sessions, since).Playtime)
+		},
+		format: func(score float64) string { return formatDuration(time.Duration(score)) },
+	},
+	{
+		Name:  "sessions",
+		Label: "Session count",
+		score: func(sessions []Session, since time.Time) float64 {
+			return float64(summarizeSessions(sessions, since).Sessions)
+		},
+		format: func(score float64) string { return strconv.Itoa(int(score)) + " sessions" },
+	},
+	{
+		Name:  "bounty",
+		Label: "Highest bounty",
+		score: func(sessions []Session, since time.Time) float64 {
+			var highest float64
+			for _, s := range sessions {
+				highest = max(highest, s.Bounty)
+			}
+			return highest
+		},
+		format:  formatBounty,
+		privacy: func(p Privacy) Visibility { return p.Bounty },
+	},
+	{
+		Name:  "streak",
+		Label: "Longest streak",
+		score: func(sessions []Session, since time.Time) float64 {
+			return float64(longestStreak(sessions, since))
+		},
+		format: func(score float64) string {
+			if score == 1 {
+				return "1 day"
+			}
+			return strconv.Itoa(int(score)) + " days"
+		},
+	},
+}
+
+func categoryChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, c := range leaderboardCategories {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: c.Label, Value: c.Name})
+	}
+	return choices
+}
+
+func findCategory(name string) (leaderboardCategory, bool) {
+	for _, c := range leaderboardCategories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return leaderboardCategory{}, false
+}
+
+// longestStreak returns the most consecutive days (UTC) with at least one
+// session after since.
+func longestStreak(sessions []Session, since time.Time) int {
+	played := make(map[time.Time]bool)
+	for _, s := range sessions {
+		start := s.Start
+		if start.Before(since) {
+			start = since
+		}
+		for day := truncateDay(start); !day.After(s.End); day = day.AddDate(0, 0, 1) {
+			played[day] = true
+		}
+	}
+
+	longest := 0
+	for day := range played {
+		// Only count from the first day of each streak
+		if played[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		n := 1
+		for played[day.AddDate(0, 0, n)] {
+			n++
+		}
+		longest = max(longest, n)
+	}
+	return longest
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+type leaderboardEntry struct {
+	DiscordID string
+	Score     float64
+}
+
+// rankPlayers scores the sessions of every player and sorts them best first,
+// leaving out players without a score.
+func rankPlayers(sessions []Session, since time.Time, category leaderboardCategory) []leaderboardEntry {
+	byPlayer := make(map[string][]Session)
+	for _, s := range sessions {
+		byPlayer[s.DiscordId] = append(byPlayer[s.DiscordId], s)
+	}
+
+	var entries []leaderboardEntry
+	for id, playerSessions := range byPlayer {
+		if score := category.score(playerSessions, since); score > 0 {
+			entries = append(entries, leaderboardEntry{DiscordID: id, Score: score})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].DiscordID < entries[j].DiscordID
+	})
+	return entries
+}
+
+type ranking struct {
+	entries []leaderboardEntry
+	ranked  time.Time
+}
+
+// ranking returns the players of the namespace ranked in category. With
+// cached set a ranking younger than rankingTTL is reused, so turning pages
+// does not read all sessions again.
+func (b *Bot) ranking(namespace string, category leaderboardCategory, period statsPeriod, cached bool) ([]leaderboardEntry, error) {
+	key := namespace + ":" + category.Name + ":" + period.Name
+	now := time.Now()
+	if cached {
+		b.rankingsMu.Lock()
+		r, ok := b.rankings[key]
+		b.rankingsMu.Unlock()
+		if ok && now.Sub(r.ranked) < rankingTTL {
+			return r.entries, nil
+		}
+	}
+
+	since := period.since(now)
+	sessions, err := b.Sessions.Sessions(context.TODO(), namespace, "", since)
+	if err != nil {
+		return nil, err
+	}
+	entries := rankPlayers(sessions, since, category)
+
+	b.rankingsMu.Lock()
+	defer b.rankingsMu.Unlock()
+	if b.rankings == nil {
+		b.rankings = make(map[string]ranking)
+	}
+	b.rankings[key] = ranking{entries: entries, ranked: now}
+	return entries, nil
+}
+
+// visibleEntries leaves out the entries whose score the players hide from
+// viewers on the given platform.
+func (b *Bot) visibleEntries(namespace string, entries []leaderboardEntry, category leaderboardCategory, viewer string) ([]leaderboardEntry, error) {
+	if category.privacy == nil || len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]string, len(entries))
+	for n, e := range entries {
+		ids[n] = e.DiscordID
+	}
+	players, err := b.Players.Players(context.TODO(), namespace, ids)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[string]bool)
+	for n := range players {
+		p := &players[n]
+		hidden[p.DiscordId] = !p.shows(category.privacy(p.Privacy), viewer)
+	}
+
+	var visible []leaderboardEntry
+	for _, e := range entries {
+		if !hidden[e.DiscordID] {
+			visible = append(visible, e)
+		}
+	}
+	return visible, nil
+}
+
+// leaderboard builds the given page of a leaderboard as shown to viewers on
+// the given platform along with its pagination buttons.
+func (b *Bot) leaderboard(g *guild, category leaderboardCategory, period statsPeriod, page int, cached bool, viewer string) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	entries, err := b.ranking(g.Namespace, category, period, cached)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Rankings are cached for all viewers, so privacy is applied to each page
+	entries, err = b.visibleEntries(g.Namespace, entries, category, viewer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pages := max(1, (len(entries)+leaderboardPageSize-1)/leaderboardPageSize)
+	page = min(max(page, 0), pages-1)
+
+	embed := &discordgo.MessageEmbed{
+		Type:  discordgo.EmbedTypeRich,
+		Color: colorBlurple,
+		Title: category.Label + " leaderboard for " + period.Label,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d of %d", page+1, pages),
+		},
+	}
+	if len(entries) == 0 {
+		embed.Description = "Nobody made it onto this leaderboard yet."
+	}
+	for n := page * leaderboardPageSize; n < min(len(entries), (page+1)*leaderboardPageSize); n++ {
+		e := entries[n]
+		embed.Description += fmt.Sprintf("**%d.** <@%s> %s\n", n+1, e.DiscordID, category.format(e.Score))
+	}
+
+	buttons := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID("leaderboard", category.Name, period.Name, strconv.Itoa(max(page-1, 0))),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID("leaderboard", category.Name, period.Name, strconv.Itoa(page+1)),
+					Disabled: page >= pages-1,
+				},
+			},
+		},
+	}
+	return embed, buttons, nil
+}
+
+func (b *Bot) showLeaderboard(g *guild, i *interaction) error {
+	category, ok := findCategory(i.option("category"))
+	if !ok {
+		category = leaderboardCategories[0]
+	}
+	viewer, _ := g.platform(i.ChannelID)
+	embed, buttons, err := b.leaderboard(g, category, findPeriod(i.option("period")), 0, false, viewer)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: buttons,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+// turnLeaderboardPage shows the page of the leaderboard the pressed button points to.
+func (b *Bot) turnLeaderboardPage(g *guild, i *interaction) error {
+	category, ok := findCategory(i.param("category"))
+	if !ok {
+		return fmt.Errorf("unknown leaderboard category %q", i.param("category"))
+	}
+	page, err := strconv.Atoi(i.param("page"))
+	if err != nil {
+		return fmt.Errorf("invalid leaderboard page: %w", err)
+	}
+	viewer, _ := g.platform(i.ChannelID)
+	embed, buttons, err := b.leaderboard(g, category, findPeriod(i.param("period")), page, true, viewer)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: buttons,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
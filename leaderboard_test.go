@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLongestStreak(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2023, 4, d, h, 0, 0, 0, time.UTC) }
+	sessions := []Session{
+		{Start: day(1, 20), End: day(1, 22)},
+		// Played past midnight, counts for both days
+		{Start: day(2, 23), End: day(3, 1)},
+		{Start: day(4, 10), End: day(4, 11)},
+		{Start: day(4, 18), End: day(4, 19)},
+		{Start: day(10, 10), End: day(10, 11)},
+	}
+
+	if got := longestStreak(sessions, time.Time{}); got != 4 {
+		t.Errorf("longestStreak = %d, want 4", got)
+	}
+	// Days before the window do not count
+	if got := longestStreak(sessions, day(3, 0)); got != 2 {
+		t.Errorf("longestStreak since day 3 = %d, want 2", got)
+	}
+	if got := longestStreak(nil, time.Time{}); got != 0 {
+		t.Errorf("longestStreak without sessions = %d, want 0", got)
+	}
+}
+
+func TestRankPlayers(t *testing.T) {
+	now := time.Date(2023, 4, 30, 20, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{DiscordId: "1", Bounty: 5, Start: now.Add(-3 * time.Hour), End: now.Add(-2 * time.Hour)},
+		{DiscordId: "2", Bounty: 12.5, Start: now.Add(-2 * time.Hour), End: now},
+		{DiscordId: "1", Bounty: 7, Start: now.Add(-time.Hour), End: now},
+		{DiscordId: "3", Start: now.Add(-time.Hour), End: now},
+	}
+
+	for name, want := range map[string][]leaderboardEntry{
+		"playtime": {{"1", float64(2 * time.Hour)}, {"2", float64(2 * time.Hour)}, {"3", float64(time.Hour)}},
+		"sessions": {{"1", 2}, {"2", 1}, {"3", 1}},
+		// Players without a bounty are left out
+		"bounty": {{"2", 12.5}, {"1", 7}},
+	} {
+		category, _ := findCategory(name)
+		got := rankPlayers(sessions, time.Time{}, category)
+		if len(got) != len(want) {
+			t.Errorf("%s: ranking = %v, want %v", name, got, want)
+			continue
+		}
+		for n := range want {
+			if got[n] != want[n] {
+				t.Errorf("%s: ranking = %v, want %v", name, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestLeaderboardCommand(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[0].Channel)
+
+	res := f.command(testUser, channel, "leaderboard")
+	if res.Message.Embeds[0].Description != "Nobody made it onto this leaderboard yet." {
+		t.Errorf("empty leaderboard = %+v", res.Message.Embeds[0])
+	}
+
+	// Player n played n hours
+	now := time.Now()
+	for n := 1; n <= leaderboardPageSize+2; n++ {
+		err := b.Sessions.RecordSession(context.Background(), &Session{
+			Namespace: testGuildID,
+			DiscordId: strconv.Itoa(1000 + n),
+			Platform:  b.Config.Platforms[0].Name,
+			Bounty:    float64(n),
+			Start:     now.Add(-time.Duration(n) * time.Hour),
+			End:       now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res = f.commandOptions(testUser, channel, "leaderboard", map[string]any{"category": "bounty", "period": "7d"})
+	embed := res.Message.Embeds[0]
+	if embed.Title != "Highest bounty leaderboard for the last 7 days" || embed.Footer.Text != "Page 1 of 2" {
+		t.Errorf("leaderboard = %q, %q", embed.Title, embed.Footer.Text)
+	}
+	if !strings.HasPrefix(embed.Description, "**1.** <@1012> $12.00\n**2.** <@1011> $11.00\n") {
+		t.Errorf("leaderboard description = %q", embed.Description)
+	}
+	prev, next := leaderboardButtons(t, res.Message)
+	if !prev.Disabled || next.Disabled {
+		t.Errorf("first page buttons disabled = %v, %v", prev.Disabled, next.Disabled)
+	}
+
+	// Turning pages reuses the ranking
+	err := b.Sessions.RecordSession(context.Background(), &Session{
+		Namespace: testGuildID,
+		DiscordId: "1100",
+		Platform:  b.Config.Platforms[0].Name,
+		Bounty:    50,
+		Start:     now.Add(-time.Hour),
+		End:       now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res = f.click(testUser, channel, next.CustomID)
+	if res.Type != discordgo.InteractionResponseUpdateMessage {
+		t.Errorf("response type = %v, want update", res.Type)
+	}
+	embed = res.Message.Embeds[0]
+	if embed.Footer.Text != "Page 2 of 2" || embed.Description != "**11.** <@1002> $2.00\n**12.** <@1001> $1.00\n" {
+		t.Errorf("second page = %q, %q", embed.Footer.Text, embed.Description)
+	}
+	prev, next = leaderboardButtons(t, res.Message)
+	if prev.Disabled || !next.Disabled {
+		t.Errorf("last page buttons disabled = %v, %v", prev.Disabled, next.Disabled)
+	}
+
+	res = f.click(testUser, channel, prev.CustomID)
+	if res.Message.Embeds[0].Footer.Text != "Page 1 of 2" {
+		t.Errorf("previous page = %q", res.Message.Embeds[0].Footer.Text)
+	}
+
+	// The command ranks the players again
+	res = f.commandOptions(testUser, channel, "leaderboard", map[string]any{"category": "bounty", "period": "7d"})
+	if !strings.HasPrefix(res.Message.Embeds[0].Description, "**1.** <@1100> $50.00\n") {
+		t.Errorf("leaderboard description after new session = %q", res.Message.Embeds[0].Description)
+	}
+}
+
+func TestLeaderboardPrivacy(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	ctx := context.Background()
+	platform := b.Config.Platforms[0].Name
+
+	// Player 1001 hides the bounty, 1002 shows it on their platform, 1003 to everyone
+	now := time.Now()
+	for n, v := range []Visibility{VisibilityHidden, VisibilityPlatform, VisibilityPublic} {
+		key := PlayerKey{Namespace: testGuildID, DiscordID: strconv.Itoa(1001 + n)}
+		if _, err := b.Players.UpsertProfile(ctx, key, ProfileUpdate{Privacy: map[string]Visibility{"bounty": v}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Players.SetOnline(ctx, key, platform, false); err != nil {
+			t.Fatal(err)
+		}
+		err := b.Sessions.RecordSession(ctx, &Session{
+			Namespace: testGuildID,
+			DiscordId: key.DiscordID,
+			Platform:  platform,
+			Bounty:    float64(3 - n),
+			Start:     now.Add(-time.Hour),
+			End:       now,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for channel, want := range map[string]string{
+		b.Config.Platforms[0].Channel: "**1.** <@1002> $2.00\n**2.** <@1003> $1.00\n",
+		b.Config.Platforms[1].Channel: "**1.** <@1003> $1.00\n",
+		b.Config.Channels.General:     "**1.** <@1003> $1.00\n",
+	} {
+		res := f.commandOptions(testUser, f.channelID(channel), "leaderboard", map[string]any{"category": "bounty"})
+		if got := res.Message.Embeds[0].Description; got != want {
+			t.Errorf("leaderboard in %s = %q, want %q", channel, got, want)
+		}
+
+		// Pages of the cached ranking are filtered as well
+		prev, _ := leaderboardButtons(t, res.Message)
+		res = f.click(testUser, f.channelID(channel), prev.CustomID)
+		if got := res.Message.Embeds[0].Description; got != want {
+			t.Errorf("leaderboard page in %s = %q, want %q", channel, got, want)
+		}
+	}
+
+	// Other categories are not private
+	res := f.commandOptions(testUser, f.channelID(b.Config.Channels.General), "leaderboard", map[string]any{"category": "sessions"})
+	if got := strings.Count(res.Message.Embeds[0].Description, "1 sessions"); got != 3 {
+		t.Errorf("sessions leaderboard = %q, want all 3 players", res.Message.Embeds[0].Description)
+	}
+}
+
+func TestPlayers(t *testing.T) {
+	t.Parallel()
+	testPlayers(t, newMemoryPlayerStore())
+}
+
+// testPlayers looks up players by Discord ID, some of them without a profile.
+func testPlayers(t *testing.T, store PlayerStore) {
+	ctx := context.Background()
+	for _, key := range []PlayerKey{{Namespace: testGuildID, DiscordID: "1001"}, {Namespace: testGuildID, DiscordID: "1002"}, {Namespace: "other", DiscordID: "1003"}} {
+		if _, err := store.UpsertProfile(ctx, key, ProfileUpdate{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	players, err := store.Players(ctx, testGuildID, []string{"1001", "1003", "1004"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 1 || players[0].DiscordId != "1001" {
+		t.Errorf("players = %+v, want only 1001", players)
+	}
+}
+
+func leaderboardButtons(t *testing.T, m *discordgo.Message) (prev, next *discordgo.Button) {
+	t.Helper()
+	if len(m.Components) != 1 {
+		t.Fatalf("components = %v", m.Components)
+	}
+	row := m.Components[0].(*discordgo.ActionsRow)
+	return row.Components[0].(*discordgo.Button), row.Components[1].(*discordgo.Button)
+}
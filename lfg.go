@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lfgTimeout is how long a looking-for-group post stays open.
+const lfgTimeout = time.Hour
+
+// Red Dead Online posses have up to seven members
+const maxPosseSize = 7
+
+var minPosseSize = 2.0
+
+// lfgCommand offers the self-assignable roles of the config as activities.
+func lfgCommand(c *Config) *discordgo.ApplicationCommand {
+	var activities []*discordgo.ApplicationCommandOptionChoice
+	for _, r := range c.Roles {
+		activities = append(activities, &discordgo.ApplicationCommandOptionChoice{Name: r.Label, Value: r.Name})
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "lfg",
+		Description: "Look for players to join you, pinging everyone with the role of the activity.",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "activity",
+				Description: "What you want to play.",
+				Required:    true,
+				Choices:     activities,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "players",
+				Description: "Size of the group including you.",
+				Required:    true,
+				MinValue:    &minPosseSize,
+				MaxValue:    maxPosseSize,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "online_only",
+				Description: "Only ping players with the role who are online on your platform.",
+			},
+		},
+	}
+}
+
+func (b *Bot) lfgEmbed(group *Group) *discordgo.MessageEmbed {
+	role, _ := b.Config.findRole(group.Role)
+	embed := &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		Color:       colorGreen,
+		Title:       strings.TrimSpace(role.Emoji + " " + role.Label + " group"),
+		Description: "<@" + group.Host + "> is looking for players on " + b.Config.platformLabel(group.Platform) + ".",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: fmt.Sprintf("Players (%d/%d):", len(group.Players), group.Size), Value: mentions(group.Players)},
+		},
+	}
+	if group.Closed {
+		embed.Color = colorDark
+		embed.Title += " (closed)"
+	} else {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Closes:", Value: timestamp(group.Closes, "R")})
+	}
+	return embed
+}
+
+func lfgButtons(group *Group) []discordgo.MessageComponent {
+	if group.Closed {
+		return []discordgo.MessageComponent{}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: customID("lfg", group.ID.Hex())},
+			},
+		},
+	}
+}
+
+// lookForGroup posts a group in the platform channel of the caller, either
+// mentioning the role of the activity or only its members online on the platform.
+func (b *Bot) lookForGroup(g *guild, i *interaction) error {
+	host := i.Member.User.ID
+	p, err := b.Players.Player(context.TODO(), g.player(host))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	platform, ok := g.platform(i.ChannelID)
+	if !ok {
+		platform = p.Platform
+	}
+	channelID, ok := g.Channels.Platforms[platform]
+	if !ok {
+		b.respondEphemeral(i, "Please use "+g.commandMention("lfg")+" in the channel of your platform.")
+		return nil
+	}
+
+	role, _ := b.Config.findRole(i.option("activity"))
+	serverRole, ok := g.roles[role.Name]
+	if !ok {
+		return fmt.Errorf("no server role for activity %q", i.option("activity"))
+	}
+	size, _ := i.intOption("players")
+
+	group := &Group{
+		ID:        primitive.NewObjectID(),
+		GuildID:   g.GuildID,
+		ChannelID: channelID,
+		Host:      host,
+		Role:      role.Name,
+		Platform:  platform,
+		Size:      int(min(max(size, 2), maxPosseSize)),
+		Players:   []string{host},
+		Closes:    time.Now().Add(lfgTimeout),
+	}
+
+	message := &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{b.lfgEmbed(group)},
+		Components: lfgButtons(group),
+	}
+	if online, _ := i.boolOption("online_only"); online {
+		users, err := b.onlineRoleMembers(g, platform, serverRole.ID, host)
+		if err != nil {
+			return err
+		}
+		message.Content = mentionList(users)
+		message.AllowedMentions = &discordgo.MessageAllowedMentions{Users: users}
+	} else {
+		message.Content = "<@&" + serverRole.ID + ">"
+		message.AllowedMentions = &discordgo.MessageAllowedMentions{Roles: []string{serverRole.ID}}
+	}
+
+	posted, err := b.Session.ChannelMessageSendComplex(channelID, message)
+	if err != nil {
+		return err
+	}
+	group.MessageID = posted.ID
+
+	err = b.Groups.CreateGroup(context.TODO(), group)
+	if err != nil {
+		// Without the stored group the button would not work
+		if err := b.Session.ChannelMessageDelete(channelID, posted.ID); err != nil {
+			b.reportError(i.log, "Error deleting message", err)
+		}
+		return err
+	}
+
+	i.log.Info("Group posted", "group_id", group.ID.Hex(), "role", role.Name, "size", group.Size)
+	content := "Your group has been posted in <#" + channelID + ">."
+	if message.Content == "" {
+		content += " Nobody with the role is online right now."
+	}
+	b.respondEphemeral(i, content)
+	return nil
+}
+
+// onlineRoleMembers returns the players online on platform who have the role,
+// except the host.
+func (b *Bot) onlineRoleMembers(g *guild, platform, roleID, host string) ([]string, error) {
+	players, err := b.Players.OnlinePlayers(context.TODO(), g.Namespace, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []string
+	for _, p := range players {
+		if p.DiscordId == host {
+			continue
+		}
+		// Members are cached from the gateway, players missing there left
+		// the server
+		member, err := b.Session.State.Member(g.GuildID, p.DiscordId)
+		if err != nil {
+			continue
+		}
+		if contains(member.Roles, roleID) {
+			users = append(users, p.DiscordId)
+		}
+	}
+	return users, nil
+}
+
+func mentionList(ids []string) string {
+	var list []string
+	for _, id := range ids {
+		list = append(list, "<@"+id+">")
+	}
+	return strings.Join(list, " ")
+}
+
+// joinGroup adds the user to the group and closes it once it is full.
+func (b *Bot) joinGroup(i *interaction) error {
+	id, err := primitive.ObjectIDFromHex(i.param("groupID"))
+	if err != nil {
+		return fmt.Errorf("invalid group ID: %w", err)
+	}
+
+	group, err := b.Groups.JoinGroup(context.TODO(), id, i.userID())
+	if err == ErrGroupClosed {
+		b.respondEphemeral(i, "This group is no longer open.")
+		return nil
+	} else if err == ErrAlreadyJoined {
+		b.respondEphemeral(i, "You are already in this group.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{b.lfgEmbed(group)},
+			Components: lfgButtons(group),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+// runGroupClosing closes groups that timed out until ctx is cancelled. Groups
+// are stored, so those timing out during a restart are closed afterwards.
+func (b *Bot) runGroupClosing(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.closeGroups(ctx, now)
+		}
+	}
+}
+
+// closeGroups closes the groups that timed out by now.
+func (b *Bot) closeGroups(ctx context.Context, now time.Time) {
+	groups, err := b.Groups.CloseGroups(ctx, now)
+	if err != nil {
+		b.reportError(slog.Default(), "Error closing groups", err)
+	}
+
+	for n := range groups {
+		group := &groups[n]
+		_, err := b.Session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         group.MessageID,
+			Channel:    group.ChannelID,
+			Embeds:     []*discordgo.MessageEmbed{b.lfgEmbed(group)},
+			Components: lfgButtons(group),
+		})
+		// Posts may have been deleted in the meantime
+		if err != nil && !isUnknownMessage(err) {
+			b.reportError(slog.With("guild_id", group.GuildID, "group_id", group.ID.Hex()), "Error editing message", err)
+		}
+	}
+}
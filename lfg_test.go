@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLookingForGroup(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+	platform := b.Config.Platforms[1]
+	channel := f.channelID(platform.Channel)
+	role := b.Config.Roles[0]
+	roleID := f.roleID(role.Name)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	sadie := &discordgo.User{ID: "302", Username: "sadie"}
+
+	for _, user := range []*discordgo.User{testUser, john, sadie} {
+		f.submit(user, channel, "setup:"+user.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+		f.command(user, channel, "online")
+	}
+	f.setRoles(john.ID, roleID)
+
+	lastMessage := func() *discordgo.Message {
+		t.Helper()
+		messages := f.channelMessages(channel)
+		return messages[len(messages)-1]
+	}
+
+	// Used outside the platform channel the group goes to the platform of the player
+	res := f.commandOptions(testUser, general, "lfg", map[string]any{"activity": role.Name, "players": 3})
+	if !strings.Contains(res.Message.Content, "<#"+channel+">") {
+		t.Errorf("/lfg = %q", res.Message.Content)
+	}
+	group := lastMessage()
+	if group.Content != "<@&"+roleID+">" || group.Embeds[0].Title != role.Emoji+" "+role.Label+" group" {
+		t.Errorf("group message = %q, %q", group.Content, group.Embeds[0].Title)
+	}
+	join := group.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+
+	res = f.click(john, channel, join)
+	if res.Type != discordgo.InteractionResponseUpdateMessage || eventFields(res.Message.Embeds[0])["Players (2/3):"] != "<@"+testUser.ID+">\n<@"+john.ID+">" {
+		t.Errorf("after joining = %+v", res.Message.Embeds[0].Fields)
+	}
+	res = f.click(john, channel, join)
+	if res.Message.Content != "You are already in this group." {
+		t.Errorf("joining twice = %q", res.Message.Content)
+	}
+	res = f.click(sadie, channel, join)
+	if !strings.HasSuffix(res.Message.Embeds[0].Title, "(closed)") || len(res.Message.Components) != 0 {
+		t.Errorf("full group = %q with %d components", res.Message.Embeds[0].Title, len(res.Message.Components))
+	}
+	res = f.click(sadie, channel, join)
+	if res.Message.Content != "This group is no longer open." {
+		t.Errorf("joining a closed group = %q", res.Message.Content)
+	}
+
+	// Only online players with the role are mentioned
+	f.commandOptions(testUser, channel, "lfg", map[string]any{"activity": role.Name, "players": 2, "online_only": true})
+	group = lastMessage()
+	if group.Content != "<@"+john.ID+">" {
+		t.Errorf("online only group mentions %q", group.Content)
+	}
+
+	join = group.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+
+	// A group whose post was deleted closes quietly
+	f.commandOptions(testUser, channel, "lfg", map[string]any{"activity": role.Name, "players": 4})
+	if err := b.Session.ChannelMessageDelete(channel, lastMessage().ID); err != nil {
+		t.Fatal(err)
+	}
+
+	b.closeGroups(context.Background(), time.Now().Add(lfgTimeout))
+	group = lastMessage()
+	if !strings.HasSuffix(group.Embeds[0].Title, "(closed)") || len(group.Components) != 0 {
+		t.Errorf("timed out group = %q with %d components", group.Embeds[0].Title, len(group.Components))
+	}
+	res = f.click(sadie, channel, join)
+	if res.Message.Content != "This group is no longer open." {
+		t.Errorf("joining a timed out group = %q", res.Message.Content)
+	}
+}
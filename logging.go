@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+func initializeLogger(logLevel string) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(logLevel))
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true, Level: level})
+	slog.SetDefault(slog.New(handler))
+	if logLevel != "" && err != nil {
+		slog.Warn("Invalid log level, using INFO", "level", logLevel, "err", err)
+	}
+}
+
+// reportError notifies Airbrake about err and logs it with the caller as source.
+// Errors are counted here, as Airbrake drops notices when its queue is full.
+func (b *Bot) reportError(logger *slog.Logger, msg string, err error) {
+	if b.metrics != nil {
+		b.metrics.errors.Inc()
+	}
+	b.ErrorReport.Notify(err, nil)
+	logError(logger, 1, msg, err)
+}
+
+// fatal logs err with the caller as source and exits.
+func fatal(msg string, err error) {
+	logError(slog.Default(), 1, msg, err)
+	os.Exit(1)
+}
+
+// logError logs err at error level, attributing it to the function calling
+// logError or, with skip, to the caller that many frames above it.
+func logError(logger *slog.Logger, skip int, msg string, err error) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, slog.LevelError) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(2+skip, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	r.AddAttrs(slog.Any("err", err))
+	_ = logger.Handler().Handle(ctx, r)
+}
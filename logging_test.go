@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestReportErrorCountsErrors(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+
+	// The fake Airbrake drops all notices, errors are counted nevertheless
+	b.reportError(slog.Default(), "Error testing", errors.New("test"))
+	b.reportError(slog.Default(), "Error testing", errors.New("test"))
+	b.ErrorReport.Flush()
+	f.takeReportedErrors()
+
+	families, err := b.metrics.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == "rdo_errors_total" {
+			if got := family.GetMetric()[0].GetCounter().GetValue(); got != 2 {
+				t.Errorf("counted %v errors, want 2", got)
+			}
+			return
+		}
+	}
+	t.Error("errors are not exported")
+}
+
+func TestLogErrorSource(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}))
+	source := func() string {
+		var record struct {
+			Source struct{ Function string }
+		}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		return record.Source.Function
+	}
+
+	logError(logger, 0, "Error testing", errors.New("test"))
+	if got, want := source(), "github.com/BunnyTheLifeguard/rdo-discord-bot.TestLogErrorSource"; got != want {
+		t.Errorf("source of direct call = %q, want %q", got, want)
+	}
+	logErrorWrapper(logger)
+	if got, want := source(), "github.com/BunnyTheLifeguard/rdo-discord-bot.TestLogErrorSource"; got != want {
+		t.Errorf("source of wrapped call = %q, want %q", got, want)
+	}
+}
+
+// logErrorWrapper logs an error on behalf of its caller like reportError.
+func logErrorWrapper(logger *slog.Logger) {
+	logError(logger, 1, "Error testing", errors.New("test"))
+}
@@ -2,43 +2,52 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/airbrake/gobrake/v5"
 	"github.com/bwmarrin/discordgo"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Bot struct {
 	Session      *discordgo.Session
-	Collection   *mongo.Collection
+	Players      PlayerStore
+	Sessions     SessionStore
+	Guilds       GuildStore
+	Events       EventStore
+	Groups       GroupStore
+	Watches      WatchStore
+	Config       *Config
 	ErrorReport  *gobrake.Notifier
 	BotRole      string
-	GuildID      string
 	ChangelogURL string
+	// DetectPresence enables going online with the Discord presence, which
+	// needs the privileged presence intent.
+	DetectPresence bool
+	Avatars        *avatars
 
-	generalChannelID     string
-	commandsChannelID    string
-	rolesChannelID       string
-	bulletinChannelID    string
-	pcChannelID          string
-	playstationChannelID string
-	xboxChannelID        string
-
-	roleSelfAssignMessageID string
-	setupCommandID          string
-	meCommandID             string
-	onlineCommandID         string
-	offlineCommandID        string
-	showPlayersCommandID    string
+	metrics  *metrics
+	guildsMu sync.RWMutex
+	guilds   map[string]*guild
+	prepared atomic.Bool
+
+	boardsMu     sync.Mutex
+	staleBoards  map[string]bool   // namespaces whose status boards need an update
+	boardContent map[string]string // last description of each status board
+
+	rankingsMu sync.Mutex
+	rankings   map[string]ranking // leaderboards by namespace, category and period
+
+	changelogMu sync.Mutex
+	changelog   []string // messages of the changelog once downloaded
 }
 
 const (
@@ -55,79 +64,167 @@ const (
 
 func main() {
 	env := readEnv()
-	bot := Bot{GuildID: env.guildID, BotRole: env.botRole, ChangelogURL: env.changelogURL}
-
-	bot.Session = initializeBot(env)
-	bot.ErrorReport = initializeErrorReport(env)
-
-	// Database connection
-	clientOptions := options.Client().
-		ApplyURI("mongodb+srv://" + env.mongodbCreds + "@cluster0.w5ind.mongodb.net/?retryWrites=true&w=majority").
-		SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1))
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	mdbClient, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		bot.ErrorReport.Notify(err, nil)
-		log.Fatal(err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			migrateCommand(env)
+			return
+		default:
+			fatal("Unknown command", fmt.Errorf("%q, the only command is migrate", os.Args[1]))
+		}
 	}
 
-	bot.Collection = mdbClient.Database(env.dbName).Collection(env.collName)
+	bot := Bot{BotRole: env.botRole, ChangelogURL: env.changelogURL, DetectPresence: env.detectPresence, guilds: make(map[string]*guild)}
+	bot.Avatars = newAvatars(env.avatarBaseURL, env.checkAvatars)
+	bot.Config = readConfig(env.configFile)
 
-	// Create TTL index
-	_, err = bot.Collection.Indexes().CreateOne(
-		context.Background(),
-		mongo.IndexModel{
-			Keys:    bson.D{{Key: "expires", Value: 1}},
-			Options: options.Index().SetExpireAfterSeconds(1),
-		},
-	)
-	if err != nil {
-		bot.ErrorReport.Notify(err, nil)
-		log.Fatal(err)
+	bot.Session = initializeBot(env)
+	bot.ErrorReport = initializeErrorReport(env)
+	bot.metrics = newMetrics(&bot)
+
+	var mdbClient *mongo.Client
+	if env.store == "memory" {
+		slog.Warn("Using in-memory stores, data will not persist...")
+		bot.Players = newMemoryPlayerStore()
+		bot.Sessions = newMemorySessionStore()
+		bot.Guilds = newMemoryGuildStore()
+		bot.Events = newMemoryEventStore()
+		bot.Groups = newMemoryGroupStore()
+		bot.Watches = newMemoryWatchStore()
+	} else {
+		mdbClient = initializeDatabase(env, bot.ErrorReport)
+		db := mdbClient.Database(env.dbName)
+		ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+		err := migrateDatabase(ctx, env, db)
+		cancel()
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error migrating database", err)
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		players, err := newMongoPlayerStore(ctx, db.Collection(env.collName))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up player store", err)
+		}
+		bot.Players = players
+
+		// Players created before multi-guild support belong to the former single guild
+		if env.guildID != "" {
+			err = players.adoptLegacyPlayers(ctx, env.guildID)
+			if err != nil {
+				bot.ErrorReport.Notify(err, nil)
+				fatal("Error adopting legacy players", err)
+			}
+		}
+
+		bot.Sessions, err = newMongoSessionStore(ctx, db.Collection("sessions"))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up session store", err)
+		}
+
+		bot.Guilds, err = newMongoGuildStore(ctx, db.Collection("guilds"))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up guild store", err)
+		}
+
+		bot.Events, err = newMongoEventStore(ctx, db.Collection("events"))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up event store", err)
+		}
+
+		bot.Groups, err = newMongoGroupStore(ctx, db.Collection("groups"))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up group store", err)
+		}
+
+		bot.Watches, err = newMongoWatchStore(ctx, db.Collection("watchlists"))
+		if err != nil {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error setting up watch store", err)
+		}
 	}
 
-	bot.Session.AddHandler(bot.prepareServer)
-	bot.Session.AddHandler(bot.registerCommands)
-	bot.Session.AddHandler(bot.assignRole)
-	bot.Session.AddHandler(bot.unassignRole)
-	bot.Session.AddHandler(bot.userWelcome)
-
-	bot.Session.Identify.Intents |= discordgo.IntentsAllWithoutPrivileged
-	bot.Session.Identify.Intents |= discordgo.IntentGuildMembers
+	bot.addHandlers()
 
 	// Open a websocket connection to Discord and begin listening.
-	err = bot.Session.Open()
+	err := bot.Session.Open()
 	if err != nil {
 		bot.ErrorReport.Notify(err, nil)
-		log.Fatalf("Error opening session: %v", err)
+		fatal("Error opening session", err)
 	}
 
 	defer bot.ErrorReport.Close()
 	defer bot.ErrorReport.NotifyOnPanic()
-	defer bot.Session.Close()
 
-	http.HandleFunc("/", healthCheck)
-	http.ListenAndServe(":8080", nil)
+	// Workers are awaited on shutdown, so they are done with the session and
+	// database before those are closed
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){bot.runSessionSweeper, bot.runStatusBoards, bot.runEventReminders, bot.runGroupClosing} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(workerCtx)
+		}(run)
+	}
+
+	server := &http.Server{Addr: ":8080", Handler: bot.httpHandler()}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			bot.ErrorReport.Notify(err, nil)
+			fatal("Error running HTTP server", err)
+		}
+	}()
 
 	// Wait here until CTRL-C or other term signal is received.
-	log.Println("Bot is now running.  Press CTRL-C to exit.")
+	slog.Info("Bot is now running.  Press CTRL-C to exit.")
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-stop
 
-	log.Println("Bot successfully shutdown.")
+	slog.Info("Shutting down...")
+	stopWorkers()
+	workers.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		bot.reportError(slog.Default(), "Error shutting down HTTP server", err)
+	}
+	if err := bot.Session.Close(); err != nil {
+		bot.reportError(slog.Default(), "Error closing session", err)
+	}
+	if mdbClient != nil {
+		if err := mdbClient.Disconnect(ctx); err != nil {
+			bot.reportError(slog.Default(), "Error disconnecting from database", err)
+		}
+	}
+
+	slog.Info("Bot successfully shutdown.")
 }
 
-func healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	resp := make(map[string]string)
-	resp["message"] = "OK"
-	jsonRes, err := json.Marshal(resp)
-	if err != nil {
-		log.Fatalf("Error happened in JSON marshal. Err: %s", err)
+// addHandlers registers the gateway event handlers and the intents they need.
+func (b *Bot) addHandlers() {
+	b.Session.AddHandler(b.prepareServer)
+	b.Session.AddHandler(b.guildCreate)
+	b.Session.AddHandler(b.registerCommands)
+	b.Session.AddHandler(b.assignRole)
+	b.Session.AddHandler(b.unassignRole)
+	b.Session.AddHandler(b.userWelcome)
+	b.Session.AddHandler(b.presenceUpdate)
+
+	b.Session.Identify.Intents |= discordgo.IntentsAllWithoutPrivileged
+	b.Session.Identify.Intents |= discordgo.IntentGuildMembers
+	if b.DetectPresence {
+		b.Session.Identify.Intents |= discordgo.IntentGuildPresences
 	}
-	w.Write(jsonRes)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type metrics struct {
+	registry            *prometheus.Registry
+	interactions        *prometheus.CounterVec
+	interactionDuration *prometheus.HistogramVec
+	errors              prometheus.Counter
+}
+
+func newMetrics(b *Bot) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		interactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rdo_interactions_total",
+			Help: "Number of handled interactions by type and name.",
+		}, []string{"type", "name"}),
+		interactionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "rdo_interaction_duration_seconds",
+			Help:    "Time spent handling interactions by type and name.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"type", "name"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "rdo_errors_total",
+			Help: "Number of reported errors.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.interactions,
+		m.interactionDuration,
+		m.errors,
+		&onlinePlayersCollector{bot: b},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeInteraction counts a handled interaction and the time it took since start.
+func (m *metrics) observeInteraction(interactionType, name string, start time.Time) {
+	m.interactions.WithLabelValues(interactionType, name).Inc()
+	m.interactionDuration.WithLabelValues(interactionType, name).Observe(time.Since(start).Seconds())
+}
+
+var onlinePlayersDesc = prometheus.NewDesc(
+	"rdo_players_online",
+	"Number of players currently online by namespace and platform.",
+	[]string{"namespace", "platform"}, nil,
+)
+
+// onlinePlayersCollector reads the online players from the store on every scrape.
+type onlinePlayersCollector struct {
+	bot *Bot
+}
+
+func (c *onlinePlayersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- onlinePlayersDesc
+}
+
+func (c *onlinePlayersCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, namespace := range c.bot.namespaces() {
+		for _, p := range c.bot.Config.Platforms {
+			players, err := c.bot.Players.OnlinePlayers(ctx, namespace, p.Name)
+			if err != nil {
+				logError(slog.Default(), 0, "Error reading online players", err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(onlinePlayersDesc, prometheus.GaugeValue, float64(len(players)), namespace, p.Name)
+		}
+	}
+}
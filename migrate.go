@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationTimeout bounds applying all pending migrations.
+const migrationTimeout = 5 * time.Minute
+
+// migration changes the schema of existing documents. Migrations must be
+// idempotent, as another instance may run the same migration concurrently.
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, players *mongo.Collection) error
+}
+
+// migrations are applied in order and must never be reordered or removed.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "Store player time as date instead of RFC 3339 string",
+		up: func(ctx context.Context, players *mongo.Collection) error {
+			_, err := players.UpdateMany(ctx,
+				bson.D{{Key: "time", Value: bson.D{{Key: "$type", Value: "string"}}}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "time", Value: bson.D{{Key: "$dateFromString", Value: bson.D{
+					{Key: "dateString", Value: "$time"},
+					{Key: "onError", Value: "$$REMOVE"},
+				}}}}}}}},
+			)
+			return err
+		},
+	},
+	{
+		version:     2,
+		description: "Store player bounty as number instead of string",
+		up: func(ctx context.Context, players *mongo.Collection) error {
+			// Bounties were free text, so tolerate a leading $ and decimal commas
+			input := bson.D{{Key: "$replaceAll", Value: bson.D{
+				{Key: "input", Value: bson.D{{Key: "$trim", Value: bson.D{{Key: "input", Value: "$bounty"}, {Key: "chars", Value: " $"}}}}},
+				{Key: "find", Value: ","},
+				{Key: "replacement", Value: "."},
+			}}}
+			_, err := players.UpdateMany(ctx,
+				bson.D{{Key: "bounty", Value: bson.D{{Key: "$type", Value: "string"}}}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "bounty", Value: bson.D{{Key: "$convert", Value: bson.D{
+					{Key: "input", Value: input},
+					{Key: "to", Value: "double"},
+					{Key: "onError", Value: "$$REMOVE"},
+					{Key: "onNull", Value: "$$REMOVE"},
+				}}}}}}}},
+			)
+			return err
+		},
+	},
+	{
+		version:     3,
+		description: "Drop player bounties outside 0-100 and round them to cents",
+		up: func(ctx context.Context, players *mongo.Collection) error {
+			_, err := players.UpdateMany(ctx,
+				bson.D{{Key: "$or", Value: bson.A{
+					bson.D{{Key: "bounty", Value: bson.D{{Key: "$lt", Value: 0}}}},
+					bson.D{{Key: "bounty", Value: bson.D{{Key: "$gt", Value: maxBounty}}}},
+					bson.D{{Key: "bounty", Value: math.NaN()}},
+				}}},
+				bson.D{{Key: "$unset", Value: bson.D{{Key: "bounty", Value: ""}}}},
+			)
+			if err != nil {
+				return err
+			}
+			_, err = players.UpdateMany(ctx,
+				bson.D{{Key: "bounty", Value: bson.D{{Key: "$type", Value: "number"}}}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "bounty", Value: bson.D{{Key: "$round", Value: bson.A{"$bounty", 2}}}}}}}},
+			)
+			return err
+		},
+	},
+}
+
+// schemaMigration records an applied migration in the schema_migrations collection.
+type schemaMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// pendingMigrations returns the migrations not applied to db yet.
+func pendingMigrations(ctx context.Context, db *mongo.Database) ([]migration, error) {
+	cursor, err := db.Collection("schema_migrations").Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	var applied []schemaMigration
+	if err = cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool)
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if !done[m.version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// runMigrations applies all pending migrations to the players collection.
+func runMigrations(ctx context.Context, db *mongo.Database, players *mongo.Collection) error {
+	pending, err := pendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		slog.Info("Database schema is up to date")
+		return nil
+	}
+
+	for _, m := range pending {
+		slog.Info("Applying migration", "version", m.version, "description", m.description)
+		if err := m.up(ctx, players); err != nil {
+			return fmt.Errorf("migration %d: %w", m.version, err)
+		}
+
+		_, err := db.Collection("schema_migrations").ReplaceOne(ctx,
+			bson.D{{Key: "_id", Value: m.version}},
+			schemaMigration{Version: m.version, Description: m.description, AppliedAt: time.Now()},
+			options.Replace().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("recording migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// migrateDatabase brings the schema up to date or, if automatic migrations are
+// disabled, fails when migrations are pending.
+func migrateDatabase(ctx context.Context, e *Env, db *mongo.Database) error {
+	if !e.autoMigrate {
+		pending, err := pendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, run the migrate command first", len(pending))
+		}
+		return nil
+	}
+	return runMigrations(ctx, db, db.Collection(e.collName))
+}
+
+// migrateCommand applies pending migrations and returns, for running them
+// separately from the bot with automatic migrations disabled.
+func migrateCommand(e *Env) {
+	errorReport := initializeErrorReport(e)
+	defer errorReport.Close()
+
+	client := initializeDatabase(e, errorReport)
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+	defer client.Disconnect(ctx)
+
+	db := client.Database(e.dbName)
+	if err := runMigrations(ctx, db, db.Collection(e.collName)); err != nil {
+		errorReport.Notify(err, nil)
+		fatal("Error migrating database", err)
+	}
+	slog.Info("Migrations complete")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	for n, m := range migrations {
+		if m.version != n+1 {
+			t.Errorf("migration %q has version %d, want %d", m.description, m.version, n+1)
+		}
+		if m.up == nil {
+			t.Errorf("migration %d has no up function", m.version)
+		}
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+	players := db.Collection("players")
+
+	// Documents as written before the migrations
+	_, err := players.InsertMany(ctx, []any{
+		bson.D{{Key: "discord_id", Value: "1"}, {Key: "time", Value: "2023-04-01T18:30:00Z"}, {Key: "bounty", Value: "$12,5"}},
+		bson.D{{Key: "discord_id", Value: "2"}, {Key: "time", Value: "yesterday"}, {Key: "bounty", Value: " 7 "}},
+		bson.D{{Key: "discord_id", Value: "3"}, {Key: "bounty", Value: "a lot"}},
+		bson.D{{Key: "discord_id", Value: "4"}, {Key: "bounty", Value: "150"}},
+		bson.D{{Key: "discord_id", Value: "5"}, {Key: "bounty", Value: "-3"}},
+		bson.D{{Key: "discord_id", Value: "6"}, {Key: "bounty", Value: "12.346"}},
+		bson.D{{Key: "discord_id", Value: "7"}, {Key: "bounty", Value: math.NaN()}},
+		bson.D{{Key: "discord_id", Value: "8"}, {Key: "bounty", Value: ""}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runMigrations(ctx, db, players); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := pendingMigrations(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) > 0 {
+		t.Errorf("%d migrations still pending", len(pending))
+	}
+
+	player := func(id string) bson.M {
+		t.Helper()
+		var doc bson.M
+		if err := players.FindOne(ctx, bson.D{{Key: "discord_id", Value: id}}).Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	if got, ok := player("1")["time"].(primitive.DateTime); !ok || !got.Time().Equal(time.Date(2023, 4, 1, 18, 30, 0, 0, time.UTC)) {
+		t.Errorf("time = %v, want date 2023-04-01 18:30 UTC", player("1")["time"])
+	}
+	if got, ok := player("2")["time"]; ok {
+		t.Errorf("unparsable time = %v, want removed", got)
+	}
+
+	bounties := map[string]float64{"1": 12.5, "2": 7, "6": 12.35}
+	for id, want := range bounties {
+		if got := player(id)["bounty"]; got != want {
+			t.Errorf("player %s: bounty = %v, want %v", id, got, want)
+		}
+	}
+	for _, id := range []string{"3", "4", "5", "7", "8"} {
+		if got, ok := player(id)["bounty"]; ok {
+			t.Errorf("player %s: bounty = %v, want removed", id, got)
+		}
+	}
+
+	// Running them again changes nothing
+	for _, m := range migrations {
+		if err := m.up(ctx, players); err != nil {
+			t.Fatalf("migration %d: %v", m.version, err)
+		}
+	}
+	if got := player("6")["bounty"]; got != 12.35 {
+		t.Errorf("bounty after running again = %v, want 12.35", got)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// onlinePlayer reads the caller, answering players who are not set up or not
+// online. It returns nil if the caller was answered.
+func (b *Bot) onlinePlayer(g *guild, i *interaction) (*Player, error) {
+	p, err := b.Players.Player(context.TODO(), g.player(i.userID()))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !p.Online {
+		b.respondEphemeral(i, "Please go online with "+g.commandMention("online")+" first.")
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (b *Bot) createPosse(g *guild, i *interaction) error {
+	p, err := b.onlinePlayer(g, i)
+	if p == nil {
+		return err
+	}
+	if p.Posse != "" {
+		b.respondEphemeral(i, "You are already in a posse, please leave it first.")
+		return nil
+	}
+
+	err = b.Players.SetPosse(context.TODO(), g.player(p.DiscordId), p.DiscordId)
+	if err != nil {
+		return err
+	}
+	i.log.Info("Posse created")
+	b.respondEphemeral(i, "You lead a posse now. Invite players online on "+b.Config.platformLabel(p.Platform)+" with "+g.commandMention("posse invite")+".")
+	return nil
+}
+
+func (b *Bot) inviteToPosse(g *guild, i *interaction) error {
+	p, err := b.onlinePlayer(g, i)
+	if p == nil {
+		return err
+	}
+	target := i.userOption("player")
+	invited, err := b.Players.Player(context.TODO(), g.player(target))
+	if err != nil && err != ErrPlayerNotFound {
+		return err
+	}
+
+	var content string
+	switch {
+	case p.Posse != p.DiscordId:
+		content = "Only the leader of a posse can invite players. Use " + g.commandMention("posse create") + " to start one."
+	case target == p.DiscordId:
+		content = "You are already in your posse."
+	case invited == nil || !invited.Online || invited.Platform != p.Platform:
+		content = "<@" + target + "> is not online on " + b.Config.platformLabel(p.Platform) + "."
+	case invited.Posse != "":
+		content = "<@" + target + "> is already in a posse."
+	}
+	if content != "" {
+		b.respondEphemeral(i, content)
+		return nil
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         "<@" + target + ">, <@" + p.DiscordId + "> invites you to their posse.",
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{target}},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: "Join Posse", Style: discordgo.SuccessButton, CustomID: customID("posse", p.DiscordId, target)},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+// joinPosse accepts an invitation if the posse still exists and has room.
+func (b *Bot) joinPosse(g *guild, i *interaction) error {
+	leaderID := i.param("leaderID")
+	if i.userID() != i.param("userID") {
+		b.respondEphemeral(i, "This invitation is not for you.")
+		return nil
+	}
+	p, err := b.onlinePlayer(g, i)
+	if p == nil {
+		return err
+	}
+
+	leader, err := b.Players.Player(context.TODO(), g.player(leaderID))
+	if err != nil && err != ErrPlayerNotFound {
+		return err
+	}
+	var content string
+	switch {
+	case err == ErrPlayerNotFound || !leader.Online || leader.Posse != leaderID:
+		content = "This posse no longer exists."
+	case p.Posse == leaderID:
+		content = "You are already in this posse."
+	case p.Posse != "":
+		content = "You are already in a posse, please leave it first."
+	case p.Platform != leader.Platform:
+		content = "This posse plays on " + b.Config.platformLabel(leader.Platform) + "."
+	}
+	if content != "" {
+		b.respondEphemeral(i, content)
+		return nil
+	}
+
+	err = b.Players.JoinPosse(context.TODO(), g.player(p.DiscordId), leaderID, maxPosseSize)
+	if err == ErrPosseFull {
+		b.respondEphemeral(i, "This posse is full.")
+		return nil
+	} else if err == ErrAlreadyInPosse {
+		b.respondEphemeral(i, "You are already in a posse, please leave it first.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "<@" + p.DiscordId + "> joined the posse of <@" + leaderID + ">.",
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) leavePosse(g *guild, i *interaction) error {
+	p, err := b.Players.Player(context.TODO(), g.player(i.userID()))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	content := "You left the posse."
+	switch p.Posse {
+	case "":
+		content = "You are not in a posse."
+	case p.DiscordId:
+		err = b.Players.DisbandPosse(context.TODO(), g.Namespace, p.DiscordId)
+		content = "You left and disbanded your posse."
+	default:
+		err = b.Players.SetPosse(context.TODO(), g.player(p.DiscordId), "")
+	}
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+	b.respondEphemeral(i, content)
+	return nil
+}
+
+func (b *Bot) disbandPosse(g *guild, i *interaction) error {
+	p, err := b.Players.Player(context.TODO(), g.player(i.userID()))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if p.Posse != p.DiscordId {
+		b.respondEphemeral(i, "Only the leader of a posse can disband it.")
+		return nil
+	}
+
+	err = b.Players.DisbandPosse(context.TODO(), g.Namespace, p.DiscordId)
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+	i.log.Info("Posse disbanded")
+	b.respondEphemeral(i, "Your posse has been disbanded.")
+	return nil
+}
+
+// groupPosses groups online players into posses with their leader first.
+// Players on their own and members whose leader is not online on the same
+// platform form groups of one. Groups are in the order of their leaders and
+// the players on their own.
+func groupPosses(players []Player) [][]Player {
+	leaders := make(map[string]bool)
+	for _, p := range players {
+		if p.Posse == p.DiscordId {
+			leaders[p.DiscordId] = true
+		}
+	}
+	member := func(p Player) bool {
+		return p.Posse != p.DiscordId && leaders[p.Posse]
+	}
+
+	var groups [][]Player
+	posses := make(map[string]int)
+	for _, p := range players {
+		if member(p) {
+			continue
+		}
+		if leaders[p.DiscordId] {
+			posses[p.DiscordId] = len(groups)
+		}
+		groups = append(groups, []Player{p})
+	}
+	for _, p := range players {
+		if member(p) {
+			n := posses[p.Posse]
+			groups[n] = append(groups[n], p)
+		}
+	}
+	return groups
+}
+
+func (b *Bot) posseEmbed(posse []Player, viewer string) *discordgo.MessageEmbed {
+	leader := posse[0]
+	view := b.view(&leader, viewer)
+
+	var members []string
+	var online time.Duration
+	for _, p := range posse {
+		member := p.Name
+		if p.shows(p.Privacy.Bounty, viewer) {
+			member += " (" + formatBounty(p.Bounty) + ")"
+		}
+		members = append(members, member)
+		online += time.Since(p.Time)
+	}
+	members[0] += " 🤠"
+
+	return &discordgo.MessageEmbed{
+		Type:      discordgo.EmbedTypeRich,
+		Color:     colorGrey,
+		Title:     leader.Name + "'s posse",
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: view.Avatar},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Members:", Value: strings.Join(members, "\n")},
+			{Name: "Camp:", Value: view.Camp, Inline: true},
+			{Name: "Total online:", Value: online.Truncate(time.Second).String(), Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: view.Footer},
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPosse(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[1].Channel)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	sadie := &discordgo.User{ID: "302", Username: "sadie"}
+	for _, user := range []*discordgo.User{testUser, john, sadie} {
+		f.submit(user, channel, "setup:"+user.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	}
+	f.click(testUser, channel, "camp_selection", b.Config.Camps[1])
+
+	res := f.command(testUser, channel, "posse create")
+	if !strings.HasPrefix(res.Message.Content, "Please go online") {
+		t.Errorf("creating a posse while offline = %q", res.Message.Content)
+	}
+	for _, user := range []*discordgo.User{testUser, john, sadie} {
+		f.command(user, channel, "online")
+	}
+	f.command(testUser, channel, "posse create")
+
+	res = f.commandOptions(john, channel, "posse invite", map[string]any{"player": sadie})
+	if !strings.HasPrefix(res.Message.Content, "Only the leader") {
+		t.Errorf("invite by a player without posse = %q", res.Message.Content)
+	}
+	res = f.commandOptions(testUser, channel, "posse invite", map[string]any{"player": john})
+	join := res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+	res = f.click(sadie, channel, join)
+	if res.Message.Content != "This invitation is not for you." {
+		t.Errorf("accepting someone else's invitation = %q", res.Message.Content)
+	}
+	res = f.click(john, channel, join)
+	if res.Type != discordgo.InteractionResponseUpdateMessage || !strings.Contains(res.Message.Content, "joined the posse") {
+		t.Errorf("accepting the invitation = %+v", res.Message)
+	}
+
+	res = f.command(sadie, channel, "show")
+	if len(res.Message.Embeds) != 2 {
+		t.Fatalf("/show responded with %d embeds, want the posse and sadie", len(res.Message.Embeds))
+	}
+	posse := res.Message.Embeds[0]
+	fields := eventFields(posse)
+	if posse.Title != testUser.Username+"'s posse" || fields["Camp:"] != b.Config.Camps[1] || fields["Members:"] != testUser.Username+" ($0.00) 🤠\njohn ($0.00)" {
+		t.Errorf("posse embed = %q with %v", posse.Title, fields)
+	}
+	if res.Message.Embeds[1].Title != sadie.Username {
+		t.Errorf("second embed = %q, want sadie alone", res.Message.Embeds[1].Title)
+	}
+
+	// Going offline leaves the posse
+	f.command(john, channel, "offline")
+	f.command(john, channel, "online")
+	res = f.command(sadie, channel, "show")
+	if len(res.Message.Embeds) != 3 {
+		t.Errorf("/show after john went offline responded with %d embeds, want 3 players", len(res.Message.Embeds))
+	}
+
+	// The posse breaks up when its leader leaves
+	res = f.commandOptions(testUser, channel, "posse invite", map[string]any{"player": sadie})
+	join = res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.Button).CustomID
+	f.click(sadie, channel, join)
+	res = f.command(sadie, channel, "posse disband")
+	if res.Message.Content != "Only the leader of a posse can disband it." {
+		t.Errorf("disband by a member = %q", res.Message.Content)
+	}
+	f.command(testUser, channel, "posse leave")
+	res = f.command(sadie, channel, "posse leave")
+	if res.Message.Content != "You are not in a posse." {
+		t.Errorf("leaving a disbanded posse = %q", res.Message.Content)
+	}
+	res = f.click(sadie, channel, join)
+	if res.Message.Content != "This posse no longer exists." {
+		t.Errorf("joining a disbanded posse = %q", res.Message.Content)
+	}
+}
+
+func TestJoinPosseLimit(t *testing.T) {
+	t.Parallel()
+	testJoinPosseLimit(t, newMemoryPlayerStore())
+}
+
+// testJoinPosseLimit lets more players join a posse at once than fit in it.
+func testJoinPosseLimit(t *testing.T, store PlayerStore) {
+	ctx := context.Background()
+	key := func(n int) PlayerKey { return PlayerKey{Namespace: testGuildID, DiscordID: strconv.Itoa(1000 + n)} }
+	for n := 0; n <= 2*maxPosseSize+1; n++ {
+		if _, err := store.UpsertProfile(ctx, key(n), ProfileUpdate{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leader := key(0).DiscordID
+	if err := store.SetPosse(ctx, key(0), leader); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var joined []int
+	for n := 1; n <= 2*maxPosseSize; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			err := store.JoinPosse(ctx, key(n), leader, maxPosseSize)
+			if err != nil && err != ErrPosseFull {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				joined = append(joined, n)
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	members := func() int {
+		t.Helper()
+		members := 0
+		for n := 0; n <= 2*maxPosseSize+1; n++ {
+			p, err := store.Player(ctx, key(n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Posse == leader {
+				members++
+			}
+		}
+		return members
+	}
+	// The posse fills up, as players only give up on joining when it is full
+	if got := members(); got != maxPosseSize || len(joined) != maxPosseSize-1 {
+		t.Errorf("posse has %d members with %d joined, want %d", got, len(joined), maxPosseSize)
+	}
+
+	// Members stay in their posse when invited to another one
+	other := key(2*maxPosseSize + 1)
+	if err := store.SetPosse(ctx, other, other.DiscordID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.JoinPosse(ctx, key(joined[0]), other.DiscordID, maxPosseSize); err != ErrAlreadyInPosse {
+		t.Errorf("joining another posse failed with %v, want %v", err, ErrAlreadyInPosse)
+	}
+
+	// Places of members who left are free again
+	if err := store.SetPosse(ctx, key(joined[0]), ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetOffline(ctx, key(joined[1])); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{joined[0], joined[1]} {
+		if err := store.JoinPosse(ctx, key(n), leader, maxPosseSize); err != nil {
+			t.Errorf("rejoining after leaving failed with %v", err)
+		}
+	}
+	if got := members(); got != maxPosseSize {
+		t.Errorf("posse has %d members after rejoining, want %d", got, maxPosseSize)
+	}
+	for n := 1; n <= 2*maxPosseSize; n++ {
+		if !slices.Contains(joined, n) {
+			if err := store.JoinPosse(ctx, key(n), leader, maxPosseSize); err != ErrPosseFull {
+				t.Errorf("joining a full posse failed with %v, want %v", err, ErrPosseFull)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// rdoActivities are the activity names Discord shows for the game.
+var rdoActivities = []string{"Red Dead Redemption 2", "Red Dead Online"}
+
+func playingRDO(activities []*discordgo.Activity) bool {
+	for _, a := range activities {
+		if a == nil || a.Type != discordgo.ActivityTypeGame {
+			continue
+		}
+		for _, name := range rdoActivities {
+			if a.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// presenceUpdate flags opted-in players online on their last platform when
+// Discord shows them playing and offline again when they stop. Sessions
+// started with /online are left alone.
+func (b *Bot) presenceUpdate(s *discordgo.Session, pu *discordgo.PresenceUpdate) {
+	g := b.guild(pu.GuildID)
+	if g == nil || pu.User == nil {
+		return
+	}
+	ctx := context.TODO()
+	key := g.player(pu.User.ID)
+	log := g.log.With("user_id", pu.User.ID)
+
+	p, err := b.Players.Player(ctx, key)
+	if err != nil {
+		if err != ErrPlayerNotFound {
+			b.reportError(log, "Error reading player", err)
+		}
+		return
+	}
+	if !p.AutoOnline || p.Platform == "" {
+		return
+	}
+
+	playing := playingRDO(pu.Activities)
+	var embed *discordgo.MessageEmbed
+	switch {
+	case playing && !p.Online:
+		log.Info("Player started playing, flagging online", "platform", p.Platform)
+		p, err = b.Players.SetOnline(ctx, key, p.Platform, true)
+		if err != nil {
+			b.reportError(log, "Error setting player online", err)
+			return
+		}
+		b.refreshBoards(g.Namespace)
+		defer b.notifyWatchers(g, p, log)
+		embed = b.onlineEmbed(p, p.Platform)
+	case !playing && p.Online && p.Detected:
+		log.Info("Player stopped playing, flagging offline")
+		p, err = b.setOffline(ctx, key, log)
+		if err != nil {
+			b.reportError(log, "Error setting player offline", err)
+			return
+		}
+		embed = b.offlineEmbed(p)
+	default:
+		return
+	}
+
+	channelID, ok := g.Channels.Platforms[p.Platform]
+	if !ok {
+		log.Warn("No channel for platform, skipping message", "platform", p.Platform)
+		return
+	}
+	_, err = b.Session.ChannelMessageSendEmbed(channelID, embed)
+	if err != nil {
+		b.reportError(log, "Error sending message", err)
+	}
+}
+
+// setAutoOnline lets players opt in to or out of presence detection.
+func (b *Bot) setAutoOnline(g *guild, i *interaction) error {
+	p, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	enabled := i.option("mode") == "on"
+	var content string
+	switch {
+	case !b.DetectPresence:
+		content = "Automatic online detection is not enabled for this bot."
+	case enabled && p.Platform == "":
+		content = "Please use " + g.commandMention("online") + " once in the channel of your platform so the bot knows where you play."
+	default:
+		_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{AutoOnline: &enabled})
+		if err != nil {
+			return err
+		}
+		content = "You will no longer be flagged online automatically."
+		if enabled {
+			content = "You will be flagged online on **" + b.Config.platformLabel(p.Platform) + "** whenever Discord shows you playing Red Dead Redemption 2, and offline when you stop. Use " + g.commandMention("online") + " in another platform channel to switch platforms."
+		}
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
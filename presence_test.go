@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresenceDetection(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	b.DetectPresence = true
+	channel := f.channelID(b.Config.Platforms[1].Channel)
+	key := b.guild(testGuildID).player(testUser.ID)
+
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	res := f.commandOptions(testUser, channel, "auto-online", map[string]any{"mode": "on"})
+	if !strings.Contains(res.Message.Content, "once in the channel of your platform") {
+		t.Errorf("opting in without a platform = %q", res.Message.Content)
+	}
+
+	// Players who did not opt in are left alone
+	f.command(testUser, channel, "online")
+	f.command(testUser, channel, "offline")
+	f.presence(testUser, "Red Dead Redemption 2")
+	if p, _ := b.Players.Player(context.Background(), key); p.Online {
+		t.Fatal("player went online without opting in")
+	}
+
+	res = f.commandOptions(testUser, channel, "auto-online", map[string]any{"mode": "on"})
+	if !strings.Contains(res.Message.Content, b.Config.Platforms[1].Label) {
+		t.Errorf("opting in = %q", res.Message.Content)
+	}
+
+	f.presence(testUser, "Spotify")
+	f.presence(testUser, "Red Dead Online")
+	f.presence(testUser, "Red Dead Online")
+	messages := f.channelMessages(channel)
+	if len(messages) != 2 || messages[1].Embeds[0].Title != testUser.Username+" is now online." {
+		t.Fatalf("got %+v in platform channel, want board and online message", messages)
+	}
+
+	f.presence(testUser)
+	messages = f.channelMessages(channel)
+	if len(messages) != 3 || messages[2].Embeds[0].Title != testUser.Username+" is now offline." {
+		t.Fatalf("got %+v in platform channel, want offline message", messages)
+	}
+	sessions, err := b.Sessions.Sessions(context.Background(), testGuildID, testUser.ID, time.Time{})
+	if err != nil || len(sessions) != 2 {
+		t.Errorf("got sessions %v, %v, want the manual and the detected one", sessions, err)
+	}
+
+	// Stopping the game does not end sessions started with /online
+	f.command(testUser, channel, "online")
+	f.presence(testUser, "Red Dead Online")
+	f.presence(testUser)
+	if p, _ := b.Players.Player(context.Background(), key); !p.Online {
+		t.Error("manual session ended with the presence")
+	}
+
+	f.commandOptions(testUser, channel, "auto-online", map[string]any{"mode": "off"})
+	if p, _ := b.Players.Player(context.Background(), key); p.AutoOnline {
+		t.Error("player still opted in")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Visibility tells who can see a profile field.
+type Visibility string
+
+const (
+	VisibilityPublic Visibility = "public"
+	// VisibilityPlatform shows a field only where players of the owner's
+	// platform look, like the platform channel.
+	VisibilityPlatform Visibility = "platform"
+	VisibilityHidden   Visibility = "hidden"
+)
+
+var visibilities = []struct {
+	Value Visibility
+	Label string
+}{
+	{VisibilityPublic, "Everyone"},
+	{VisibilityPlatform, "Players on my platform"},
+	{VisibilityHidden, "Nobody"},
+}
+
+// Privacy holds the visibility of the profile fields of a player on all
+// platforms. Fields not set are public.
+type Privacy struct {
+	Camp   Visibility `bson:"camp,omitempty"`
+	Bounty Visibility `bson:"bounty,omitempty"`
+	Avatar Visibility `bson:"avatar,omitempty"`
+	Footer Visibility `bson:"footer,omitempty"`
+}
+
+// privacyFields are the profile fields with a visibility, by their bson key.
+var privacyFields = []struct {
+	Key   string
+	Label string
+}{
+	{"camp", "Camp"},
+	{"bounty", "Bounty"},
+	{"avatar", "R* avatar"},
+	{"footer", "Footer"},
+}
+
+// field returns the visibility of the field with the given key, or nil if
+// there is no such field.
+func (pr *Privacy) field(key string) *Visibility {
+	switch key {
+	case "camp":
+		return &pr.Camp
+	case "bounty":
+		return &pr.Bounty
+	case "avatar":
+		return &pr.Avatar
+	case "footer":
+		return &pr.Footer
+	}
+	return nil
+}
+
+// shows reports whether a field with visibility v is shown to viewers on the
+// given platform, empty if they are not on a platform.
+func (p *Player) shows(v Visibility, viewer string) bool {
+	switch v {
+	case VisibilityHidden:
+		return false
+	case VisibilityPlatform:
+		return viewer != "" && viewer == p.Platform
+	}
+	return true
+}
+
+// profileView holds the profile fields of a player as shown to a viewer.
+type profileView struct {
+	Avatar string
+	Bounty string
+	Camp   string
+	Footer string
+}
+
+// view returns the profile of p as shown to viewers on the given platform.
+func (b *Bot) view(p *Player, viewer string) profileView {
+	v := profileView{Avatar: rdoAvatarUnknownURL, Bounty: "Hidden", Camp: "Hidden"}
+	if p.shows(p.Privacy.Avatar, viewer) {
+		v.Avatar = b.Avatars.url(p.RockstarId)
+	}
+	if p.shows(p.Privacy.Bounty, viewer) {
+		v.Bounty = formatBounty(p.Bounty)
+	}
+	if p.shows(p.Privacy.Camp, viewer) {
+		v.Camp = "-"
+		if p.Camp != "" {
+			v.Camp = p.Camp
+		}
+	}
+	if p.shows(p.Privacy.Footer, viewer) {
+		v.Footer = p.Footer
+	}
+	return v
+}
+
+func (b *Bot) showPrivacy(g *guild, i *interaction) error {
+	p, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err == ErrPlayerNotFound {
+		b.respondSetupRequired(g, i)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var rows []discordgo.MessageComponent
+	for _, f := range privacyFields {
+		current := *p.Privacy.field(f.Key)
+		if current == "" {
+			current = VisibilityPublic
+		}
+		var options []discordgo.SelectMenuOption
+		for _, v := range visibilities {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:   f.Label + ": " + v.Label,
+				Value:   string(v.Value),
+				Default: v.Value == current,
+			})
+		}
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{CustomID: customID("privacy", f.Key), Options: options},
+			},
+		})
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "Choose who can see each part of your profile in " + g.commandMention("online") + ", " + g.commandMention("show") + ", " + g.commandMention("profile") + " and the status boards. These settings apply on all platforms.",
+			Components: rows,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) setPrivacy(g *guild, i *interaction) error {
+	key := i.param("field")
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return fmt.Errorf("privacy selection without a value")
+	}
+	var label, choice string
+	for _, f := range privacyFields {
+		if f.Key == key {
+			label = f.Label
+		}
+	}
+	for _, v := range visibilities {
+		if string(v.Value) == values[0] {
+			choice = v.Label
+		}
+	}
+	if label == "" || choice == "" {
+		return fmt.Errorf("invalid privacy selection %q for %q", values[0], key)
+	}
+
+	_, err := b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Privacy: map[string]Visibility{key: Visibility(values[0])}})
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	b.respondEphemeral(i, "Your "+label+" is now visible to: **"+choice+"**")
+	return nil
+}
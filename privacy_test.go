@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPrivacy(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	pc := b.Config.Platforms[0]
+	channel, general := f.channelID(pc.Channel), f.channelID(b.Config.Channels.General)
+	avatar := rdoAvatarURLPrefix + "123456789" + rdoAvatarURLSuffix
+
+	f.submit(testUser, channel, "setup:"+testUser.ID+":"+pc.Name, map[string]string{"rockstar_id": "123456789", "bounty": "12.5", "footer": "Hunting"})
+	f.click(testUser, channel, "camp_selection:"+pc.Name, b.Config.Camps[0])
+
+	res := f.click(testUser, channel, "privacy")
+	if len(res.Message.Components) != len(privacyFields) {
+		t.Fatalf("privacy panel has %d rows, want one per field", len(res.Message.Components))
+	}
+	menu := res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.SelectMenu)
+	if menu.CustomID != "privacy:camp" || !menu.Options[0].Default {
+		t.Errorf("camp privacy menu = %q with options %+v, want public selected", menu.CustomID, menu.Options)
+	}
+	f.click(testUser, channel, "privacy:camp", string(VisibilityHidden))
+	f.click(testUser, channel, "privacy:avatar", string(VisibilityPlatform))
+	f.click(testUser, channel, "privacy:footer", string(VisibilityPlatform))
+
+	embed := f.command(testUser, channel, "online").Message.Embeds[0]
+	fields := eventFields(embed)
+	if fields["Camp:"] != "Hidden" || fields["Bounty:"] != "$12.50" || embed.Thumbnail.URL != avatar || embed.Footer.Text != "Hunting" {
+		t.Errorf("/online on own platform = %v, avatar %q, footer %q", fields, embed.Thumbnail.URL, embed.Footer.Text)
+	}
+	embed = f.command(testUser, channel, "show").Message.Embeds[0]
+	if fields := eventFields(embed); fields["Camp:"] != "Hidden" || embed.Thumbnail.URL != avatar {
+		t.Errorf("/show = %v, avatar %q", fields, embed.Thumbnail.URL)
+	}
+
+	// Outside of the platform channel only public fields are shown
+	embed = f.commandOptions(john, general, "profile", map[string]any{"user": testUser}).Message.Embeds[0]
+	if fields := eventFields(embed); fields["Camp:"] != "Hidden" || fields["Bounty:"] != "$12.50" || embed.Thumbnail.URL != rdoAvatarUnknownURL || embed.Footer.Text != "" {
+		t.Errorf("/profile outside platform = %v, avatar %q, footer %q", fields, embed.Thumbnail.URL, embed.Footer.Text)
+	}
+	embed = f.commandOptions(john, channel, "profile", map[string]any{"user": testUser}).Message.Embeds[0]
+	if embed.Thumbnail.URL != avatar || embed.Footer.Text != "Hunting" {
+		t.Errorf("/profile in platform channel shows avatar %q and footer %q", embed.Thumbnail.URL, embed.Footer.Text)
+	}
+
+	f.click(testUser, channel, "privacy:bounty", string(VisibilityHidden))
+	if fields := eventFields(f.command(testUser, channel, "show").Message.Embeds[0]); fields["Bounty:"] != "Hidden" {
+		t.Errorf("hidden bounty shown as %q", fields["Bounty:"])
+	}
+
+	p, err := b.Players.Player(context.Background(), b.guild(testGuildID).player(testUser.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	board := statusBoardEmbed(pc, []Player{*p}).Description
+	if strings.Contains(board, b.Config.Camps[0]) || strings.Contains(board, "$12.50") || !strings.Contains(board, "Hunting") {
+		t.Errorf("status board = %q, want camp and bounty hidden and footer shown on its platform", board)
+	}
+}
@@ -0,0 +1,871 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// platformOption lets players pick the platform profile a command is about.
+func platformOption(c *Config) *discordgo.ApplicationCommandOption {
+	var platforms []*discordgo.ApplicationCommandOptionChoice
+	for _, p := range c.Platforms {
+		platforms = append(platforms, &discordgo.ApplicationCommandOptionChoice{Name: p.Label, Value: p.Name})
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "platform",
+		Description: "Platform of the profile, the one of this channel or your last one by default.",
+		Choices:     platforms,
+	}
+}
+
+// profilePlatform returns the platform of the profile an interaction is
+// about: the one in its custom ID or options, the one of the channel or the
+// one the player last played on. Players who never went online edit their
+// default profile, used on all platforms without their own.
+func (b *Bot) profilePlatform(g *guild, i *interaction) (string, error) {
+	if platform := i.param("platform"); platform != "" {
+		return platform, nil
+	}
+	if i.Type == discordgo.InteractionApplicationCommand {
+		if platform, ok := b.Config.findPlatform(i.option("platform")); ok {
+			return platform, nil
+		}
+	}
+	if platform, ok := g.platform(i.ChannelID); ok {
+		return platform, nil
+	}
+
+	p, err := b.Players.Player(context.TODO(), g.player(i.userID()))
+	if err == ErrPlayerNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return p.Platform, nil
+}
+
+// profileLabel names the profile of platform for display.
+func (b *Bot) profileLabel(platform string) string {
+	if platform == "" {
+		return "all platforms"
+	}
+	return b.Config.platformLabel(platform)
+}
+
+// withPlatform builds a custom ID from its segments, followed by the platform
+// unless it is the default profile.
+func withPlatform(platform string, segments ...string) string {
+	if platform != "" {
+		segments = append(segments, platform)
+	}
+	return customID(segments...)
+}
+
+func (b *Bot) openSetup(g *guild, i *interaction) error {
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title:   "Profile Setup for " + b.profileLabel(platform),
+			Content: "Enter your current data to get you started. \nYou only have to do this once or after the bot was offline.",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "rockstar_id",
+							Label:       "R* ID:",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "123456789",
+							Required:    false,
+							MinLength:   9,
+							MaxLength:   9,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "bounty",
+							Label:       "Bounty (0-100):",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "19.99",
+							Required:    false,
+							MinLength:   1,
+							MaxLength:   7,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "footer",
+							Label:       "Footer Message:",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "What are you up to?",
+							Required:    false,
+							MaxLength:   42,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: withPlatform(platform, "setup", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) submitSetup(g *guild, i *interaction) error {
+	rockstarId, err := i.fields.text("rockstar_id")
+	if err != nil {
+		return err
+	}
+	bounty, err := i.fields.text("bounty")
+	if err != nil {
+		return err
+	}
+	footer, err := i.fields.text("footer")
+	if err != nil {
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	name := i.Member.User.Username
+	if i.Member.Nick != "" {
+		name = i.Member.Nick
+	}
+	profile := ProfileUpdate{Name: &name, Platform: platform}
+	if rockstarId = strings.TrimSpace(rockstarId); rockstarId != "" {
+		if !rockstarIDPattern.MatchString(rockstarId) {
+			b.respondInvalidRockstarID(i, withPlatform(platform, "setup"))
+			return nil
+		}
+		profile.RockstarId = &rockstarId
+	}
+	if bounty != "" {
+		value, err := parseBounty(bounty)
+		if err != nil {
+			b.respondInvalidBounty(i, err, withPlatform(platform, "setup"))
+			return nil
+		}
+		profile.Bounty = &value
+	}
+	if footer != "" {
+		profile.Footer = &footer
+	}
+
+	created, err := b.Players.UpsertProfile(context.TODO(), g.player(i.Member.User.ID), profile)
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	content := "Success! Your profile for " + b.profileLabel(platform) + " has been updated."
+	if created {
+		content = "Success! Your initial profile info is now set. You can now go online, offline and show other online players."
+	}
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	if profile.RockstarId != nil {
+		b.checkAvatar(i, rockstarId)
+	}
+	return nil
+}
+
+// profileButtons are the buttons for editing the profile on platform.
+func profileButtons(platform string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Set Bounty",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_bounty"),
+				},
+				discordgo.Button{
+					Label:    "Set Camp",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_camp"),
+				},
+				discordgo.Button{
+					Label:    "Set Footer",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_footer"),
+				},
+				discordgo.Button{
+					Label:    "Set R* ID",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_rid"),
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Set Character",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_character"),
+				},
+				discordgo.Button{
+					Label:    "Set Role Ranks",
+					Style:    discordgo.SecondaryButton,
+					CustomID: withPlatform(platform, "set_role_ranks"),
+				},
+				discordgo.Button{
+					Label:    "Privacy",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "privacy",
+				},
+			},
+		},
+	}
+}
+
+func (b *Bot) showProfile(g *guild, i *interaction) error {
+	rockstarIdStatus := "R* ID is not set"
+
+	result, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err != nil {
+		if err == ErrPlayerNotFound {
+			b.respondSetupRequired(g, i)
+		} else {
+			b.reportError(i.log, "Error reading player", err)
+		}
+		return nil
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	profile := result.profile(platform)
+
+	if profile.RockstarId != "" {
+		rockstarIdStatus = "R* ID is set"
+	}
+	description := rockstarIdStatus + "\n Camp: " + profile.Camp + "\n Bounty: " + formatBounty(profile.Bounty) + "\n Footer: " + profile.Footer
+	if profile.Character != "" {
+		description += "\n Character: " + profile.Character
+	}
+	if profile.Rank > 0 {
+		description += "\n Rank: " + strconv.Itoa(profile.Rank)
+	}
+	if ranks := b.roleRanks(profile); ranks != "" {
+		description += "\n Role ranks: " + strings.ReplaceAll(ranks, "\n", ", ")
+	}
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Type:        discordgo.EmbedTypeRich,
+					Title:       "Your current profile data for " + b.profileLabel(platform) + ":",
+					Description: description,
+					Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: b.Avatars.url(profile.RockstarId)},
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+
+	content := "Update your profile data below:\n\nTo find your R* ID, visit your Social Club profile here: <https://socialclub.rockstargames.com/games/rdr2/overview>.\nOn the tiny avatar of your character do a right-click and click on *Open image in new tab*. In the browser address bar you will notice a 9-digit number (just before */pedshot_0.jpg*) which is your Rockstar ID.\n"
+	var others []string
+	for _, p := range b.Config.Platforms {
+		if p.Name != platform {
+			others = append(others, p.Label)
+		}
+	}
+	if len(others) > 0 {
+		content += "\nYou have a separate profile on each platform. Use " + g.commandMention("me") + " with the platform option to edit the one on " + strings.Join(others, ", ") + ".\n"
+	}
+	_, err = b.Session.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: content, Components: profileButtons(platform), Flags: discordgo.MessageFlagsEphemeral})
+	if err != nil {
+		b.reportError(i.log, "Error sending followup message", err)
+	}
+	return nil
+}
+
+// showMemberProfile shows the profile of another member on their current or
+// last platform.
+func (b *Bot) showMemberProfile(g *guild, i *interaction) error {
+	target := i.userOption("user")
+	p, err := b.Players.Player(context.TODO(), g.player(target))
+	if err == ErrPlayerNotFound {
+		b.respondEphemeral(i, "<@"+target+"> has not set up a profile yet. Maybe ask them to use "+g.commandMention("setup")+"?")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sessions, err := b.Sessions.Sessions(context.TODO(), g.Namespace, target, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	// Fields only shown on a platform are shown in its channel or to its players
+	viewer, ok := g.platform(i.ChannelID)
+	if !ok {
+		if self, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID)); err == nil {
+			viewer = self.Platform
+		}
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{b.memberProfileEmbed(p, summarizeSessions(sessions, time.Time{}), viewer)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) memberProfileEmbed(p *Player, stats playerStats, viewer string) *discordgo.MessageEmbed {
+	view := b.view(p, viewer)
+	platform, lastSeen, playtime := "-", "-", "-"
+	if p.Platform != "" {
+		platform = b.Config.platformLabel(p.Platform)
+	}
+	if p.Online {
+		lastSeen = "Online now"
+	} else if !p.Time.IsZero() {
+		lastSeen = timestamp(p.Time, "R")
+	}
+	if stats.Sessions > 0 {
+		playtime = formatDuration(stats.Playtime)
+	}
+
+	return &discordgo.MessageEmbed{
+		Type:      discordgo.EmbedTypeRich,
+		Color:     colorBlurple,
+		Title:     p.Name,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: view.Avatar},
+		Fields: append([]*discordgo.MessageEmbedField{
+			{Name: "Bounty:", Value: view.Bounty, Inline: true},
+			{Name: "Camp:", Value: view.Camp, Inline: true},
+			{Name: "Platform:", Value: platform, Inline: true},
+			{Name: "Last seen:", Value: lastSeen, Inline: true},
+			{Name: "Total playtime:", Value: playtime, Inline: true},
+		}, b.characterFields(p.Profile)...),
+		Footer: &discordgo.MessageEmbedFooter{Text: view.Footer},
+	}
+}
+
+func (b *Bot) askBounty(g *guild, i *interaction) error {
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title: "Set Bounty",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "bounty",
+							Label:       "Set your current bounty (0-100):",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "10.01",
+							Required:    true,
+							MinLength:   1,
+							MaxLength:   7,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: withPlatform(platform, "set_bounty", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) askCamp(g *guild, i *interaction) error {
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	selectMinVal := 1
+	campOptions := make([]discordgo.SelectMenuOption, 0, len(b.Config.Camps))
+	for _, camp := range b.Config.Camps {
+		campOptions = append(campOptions, discordgo.SelectMenuOption{Label: camp, Value: camp})
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Set your current camp location on " + b.profileLabel(platform) + ".\nYour profile will be updated as soon as you select an option.",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.SelectMenu{
+							MenuType:    3,
+							Placeholder: "Choose Location",
+							MinValues:   &selectMinVal,
+							MaxValues:   1,
+							CustomID:    withPlatform(platform, "camp_selection"),
+							Options:     campOptions,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: "select_camp_" + i.Member.User.ID,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) askFooter(g *guild, i *interaction) error {
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title:   "Footer Message",
+			Content: "Enter a message that appears in the footer of your online notification.",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "footer",
+							Label:       "Set your footer message",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "What are you up to?",
+							Required:    false,
+							MaxLength:   42,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: withPlatform(platform, "set_footer", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) askRockstarID(g *guild, i *interaction) error {
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title: "Set Rockstar ID",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "rockstar_id",
+							Label:       "Copy & Paste your R* ID:",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "123456789",
+							Required:    false,
+							MinLength:   9,
+							MaxLength:   9,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: withPlatform(platform, "set_rid", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) selectCamp(g *guild, i *interaction) error {
+	values := i.MessageComponentData().Values
+	if len(values) == 0 {
+		return fmt.Errorf("camp selection without a value")
+	}
+	camp := strings.TrimSpace(values[0])
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, Camp: &camp})
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	b.respondEphemeral(i, "Your camp location on "+b.profileLabel(platform)+" is now set to **"+camp+"**")
+	return nil
+}
+
+func (b *Bot) setFooter(g *guild, i *interaction) error {
+	footer, err := i.fields.text("footer")
+	if err != nil {
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, Footer: &footer})
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	b.respondEphemeral(i, "Your footer message on "+b.profileLabel(platform)+" is set. Feel free to change it anytime.")
+	return nil
+}
+
+func (b *Bot) setBounty(g *guild, i *interaction) error {
+	input, err := i.fields.text("bounty")
+	if err != nil {
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	bounty, err := parseBounty(input)
+	if err != nil {
+		b.respondInvalidBounty(i, err, withPlatform(platform, "set_bounty"))
+		return nil
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, Bounty: &bounty})
+	if err != nil {
+		return err
+	}
+	b.refreshBoards(g.Namespace)
+
+	b.respondEphemeral(i, "Your bounty on "+b.profileLabel(platform)+" is now set to **"+formatBounty(bounty)+"**")
+	return nil
+}
+
+func (b *Bot) setRockstarID(g *guild, i *interaction) error {
+	rockstarId, err := i.fields.text("rockstar_id")
+	if err != nil {
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	// An empty ID removes it
+	rockstarId = strings.TrimSpace(rockstarId)
+	if rockstarId != "" && !rockstarIDPattern.MatchString(rockstarId) {
+		b.respondInvalidRockstarID(i, withPlatform(platform, "set_rid"))
+		return nil
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, RockstarId: &rockstarId})
+	if err != nil {
+		return err
+	}
+
+	content := "Successfully updated your Rockstar ID on " + b.profileLabel(platform) + "."
+	if rockstarId == "" {
+		content = "Your Rockstar ID on " + b.profileLabel(platform) + " has been removed."
+	}
+	b.respondEphemeral(i, content)
+	b.checkAvatar(i, rockstarId)
+	return nil
+}
+
+// checkAvatar checks the avatar of a saved R* ID in the background and tells
+// the player in a followup message if there is none, which usually means a
+// typo.
+func (b *Bot) checkAvatar(i *interaction, rockstarId string) {
+	if rockstarId == "" || !b.Avatars.check {
+		return
+	}
+	b.Avatars.exists(rockstarId, func(found bool) {
+		if found {
+			return
+		}
+		_, err := b.Session.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content: "No Social Club avatar was found for your R* ID, please check that it is correct.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			b.reportError(i.log, "Error sending followup message", err)
+		}
+	})
+}
+
+func (b *Bot) askCharacter(g *guild, i *interaction) error {
+	result, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err != nil {
+		if err == ErrPlayerNotFound {
+			b.respondSetupRequired(g, i)
+			return nil
+		}
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	profile := result.profile(platform)
+	rank := ""
+	if profile.Rank > 0 {
+		rank = strconv.Itoa(profile.Rank)
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title: "Set Character",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "character",
+							Label:       "Name of your character:",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "Arthur Morgan",
+							Value:       profile.Character,
+							Required:    false,
+							MaxLength:   32,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "rank",
+							Label:       "Overall rank:",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "150",
+							Value:       rank,
+							Required:    false,
+							MaxLength:   4,
+						},
+					},
+				},
+			},
+			Flags:    discordgo.MessageFlagsEphemeral,
+			CustomID: withPlatform(platform, "set_character", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) setCharacter(g *guild, i *interaction) error {
+	character, err := i.fields.text("character")
+	if err != nil {
+		return err
+	}
+	input, err := i.fields.text("rank")
+	if err != nil {
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	rank, err := parseRank(input, maxRank)
+	if err != nil {
+		b.respondRetry(i, "Please enter your rank as a number from 1 to "+strconv.Itoa(maxRank)+".", withPlatform(platform, "set_character"))
+		return nil
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, Character: &character, Rank: &rank})
+	if err != nil {
+		return err
+	}
+
+	b.respondEphemeral(i, "Your character on "+b.profileLabel(platform)+" has been updated.")
+	return nil
+}
+
+// rankedRoles returns the roles with a rank in the role ranks modal. Discord
+// limits modals to 5 text inputs, so only the first 5 roles are included.
+func (b *Bot) rankedRoles() []RoleConfig {
+	if len(b.Config.Roles) > 5 {
+		return b.Config.Roles[:5]
+	}
+	return b.Config.Roles
+}
+
+func (b *Bot) askRoleRanks(g *guild, i *interaction) error {
+	result, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err != nil {
+		if err == ErrPlayerNotFound {
+			b.respondSetupRequired(g, i)
+			return nil
+		}
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+	profile := result.profile(platform)
+
+	roles := b.rankedRoles()
+	if len(roles) == 0 {
+		b.respondEphemeral(i, "This server has no roles to rank.")
+		return nil
+	}
+	var rows []discordgo.MessageComponent
+	for _, r := range roles {
+		rank := ""
+		if profile.RoleRanks[r.Name] > 0 {
+			rank = strconv.Itoa(profile.RoleRanks[r.Name])
+		}
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    r.Name,
+					Label:       r.Label + " rank (1-" + strconv.Itoa(maxRoleRank) + "):",
+					Style:       discordgo.TextInputShort,
+					Placeholder: "Leave empty if not owned",
+					Value:       rank,
+					Required:    false,
+					MaxLength:   2,
+				},
+			},
+		})
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			Title:      "Set Role Ranks",
+			Components: rows,
+			Flags:      discordgo.MessageFlagsEphemeral,
+			CustomID:   withPlatform(platform, "set_role_ranks", i.Member.User.ID),
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) setRoleRanks(g *guild, i *interaction) error {
+	result, err := b.Players.Player(context.TODO(), g.player(i.Member.User.ID))
+	if err != nil {
+		if err == ErrPlayerNotFound {
+			b.respondSetupRequired(g, i)
+			return nil
+		}
+		return err
+	}
+	platform, err := b.profilePlatform(g, i)
+	if err != nil {
+		return err
+	}
+
+	// Keep the ranks of roles missing from the modal
+	ranks := make(map[string]int)
+	for role, rank := range result.profile(platform).RoleRanks {
+		ranks[role] = rank
+	}
+	for _, r := range b.rankedRoles() {
+		input, err := i.fields.text(r.Name)
+		if err != nil {
+			return err
+		}
+		rank, err := parseRank(input, maxRoleRank)
+		if err != nil {
+			b.respondRetry(i, "Role ranks range from 1 to "+strconv.Itoa(maxRoleRank)+", please check your "+r.Label+" rank.", withPlatform(platform, "set_role_ranks"))
+			return nil
+		}
+		if rank == 0 {
+			delete(ranks, r.Name)
+		} else {
+			ranks[r.Name] = rank
+		}
+	}
+
+	_, err = b.Players.UpdateProfile(context.TODO(), g.player(i.Member.User.ID), ProfileUpdate{Platform: platform, RoleRanks: ranks})
+	if err != nil {
+		return err
+	}
+
+	b.respondEphemeral(i, "Your role ranks on "+b.profileLabel(platform)+" have been updated.")
+	return nil
+}
+
+// roleRanks lists the role ranks of a profile in the order of the configured
+// roles, one per line.
+func (b *Bot) roleRanks(p Profile) string {
+	var lines []string
+	for _, r := range b.Config.Roles {
+		if rank := p.RoleRanks[r.Name]; rank > 0 {
+			lines = append(lines, strings.TrimSpace(r.Emoji+" "+r.Label)+": "+strconv.Itoa(rank))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// characterFields are the embed fields with the character and ranks set in a
+// profile, if any.
+func (b *Bot) characterFields(p Profile) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	character := p.Character
+	if p.Rank > 0 {
+		character = strings.TrimSpace(character + " (rank " + strconv.Itoa(p.Rank) + ")")
+	}
+	if character != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Character:", Value: character})
+	}
+	if ranks := b.roleRanks(p); ranks != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Role ranks:", Value: ranks})
+	}
+	return fields
+}
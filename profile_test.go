@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPlatformProfiles(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	general := f.channelID(b.Config.Channels.General)
+	pc, ps := b.Config.Platforms[0], b.Config.Platforms[1]
+	pcChannel, psChannel := f.channelID(pc.Channel), f.channelID(ps.Channel)
+
+	onlineEmbed := func(channel string) map[string]string {
+		t.Helper()
+		res := f.command(testUser, channel, "online")
+		if len(res.Message.Embeds) != 1 {
+			t.Fatalf("/online responded with %+v", res.Message)
+		}
+		return eventFields(res.Message.Embeds[0])
+	}
+
+	// Set up outside of a platform channel the profile is used on all platforms
+	res := f.command(testUser, general, "setup")
+	if res.CustomID != "setup:"+testUser.ID {
+		t.Errorf("/setup in general opened %q, want the default profile", res.CustomID)
+	}
+	f.submit(testUser, general, res.CustomID, map[string]string{"rockstar_id": "", "bounty": "5", "footer": ""})
+	f.click(testUser, general, "camp_selection", b.Config.Camps[0])
+	if fields := onlineEmbed(psChannel); fields["Camp:"] != b.Config.Camps[0] || fields["Bounty:"] != "$5.00" {
+		t.Errorf("%s profile = %v, want the default one", ps.Label, fields)
+	}
+
+	// Editing in a platform channel only changes the profile of the platform
+	f.click(testUser, psChannel, "camp_selection", b.Config.Camps[1])
+	res = f.submit(testUser, psChannel, "set_bounty:"+testUser.ID, map[string]string{"bounty": "20"})
+	if !strings.Contains(res.Message.Content, ps.Label) {
+		t.Errorf("bounty response = %q, want the platform", res.Message.Content)
+	}
+	if fields := onlineEmbed(psChannel); fields["Camp:"] != b.Config.Camps[1] || fields["Bounty:"] != "$20.00" {
+		t.Errorf("%s profile = %v", ps.Label, fields)
+	}
+	if fields := onlineEmbed(pcChannel); fields["Camp:"] != b.Config.Camps[0] || fields["Bounty:"] != "$5.00" {
+		t.Errorf("%s profile = %v, want the default one", pc.Label, fields)
+	}
+
+	// /me edits the profile picked or the one of the current platform
+	res = f.command(testUser, general, "me")
+	if !strings.Contains(res.Message.Embeds[0].Title, pc.Label) {
+		t.Errorf("/me shows %q, want the current platform", res.Message.Embeds[0].Title)
+	}
+	res = f.commandOptions(testUser, general, "me", map[string]any{"platform": ps.Name})
+	if !strings.Contains(res.Message.Embeds[0].Description, "Camp: "+b.Config.Camps[1]) {
+		t.Errorf("/me for %s = %q", ps.Label, res.Message.Embeds[0].Description)
+	}
+	button := res.Followups[0].Components[0].(*discordgo.ActionsRow).Components[1].(*discordgo.Button)
+	res = f.click(testUser, general, button.CustomID)
+	menu := res.Message.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.SelectMenu)
+	f.click(testUser, general, menu.CustomID, b.Config.Camps[2])
+	if fields := onlineEmbed(psChannel); fields["Camp:"] != b.Config.Camps[2] {
+		t.Errorf("%s camp = %q after editing it from /me", ps.Label, fields["Camp:"])
+	}
+	if fields := onlineEmbed(pcChannel); fields["Camp:"] != b.Config.Camps[0] {
+		t.Errorf("%s camp = %q, want it unchanged", pc.Label, fields["Camp:"])
+	}
+}
+
+func TestCharacterProfile(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	pc := b.Config.Platforms[0]
+	channel := f.channelID(pc.Channel)
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+
+	res := f.click(testUser, channel, "set_role_ranks")
+	if res.Type != discordgo.InteractionResponseModal || res.CustomID != "set_role_ranks:"+testUser.ID+":"+pc.Name {
+		t.Fatalf("role ranks button responded with type %d and custom ID %q", res.Type, res.CustomID)
+	}
+	ranks := map[string]string{}
+	for _, r := range b.Config.Roles {
+		ranks[r.Name] = ""
+	}
+	ranks["Bountyhunter"] = "21"
+	res = f.submit(testUser, channel, res.CustomID, ranks)
+	if !strings.Contains(res.Message.Content, "range from 1 to 20") || len(res.Message.Components) == 0 {
+		t.Errorf("invalid role rank response = %q", res.Message.Content)
+	}
+	ranks["Bountyhunter"], ranks["Naturalist"] = "12", "20"
+	f.submit(testUser, channel, "set_role_ranks:"+testUser.ID+":"+pc.Name, ranks)
+
+	res = f.submit(testUser, channel, "set_character:"+testUser.ID+":"+pc.Name, map[string]string{"character": "Jack", "rank": "abc"})
+	if !strings.Contains(res.Message.Content, "rank as a number") {
+		t.Errorf("invalid rank response = %q", res.Message.Content)
+	}
+	f.submit(testUser, channel, "set_character:"+testUser.ID+":"+pc.Name, map[string]string{"character": "Jack", "rank": "150"})
+
+	check := func(command string, embed *discordgo.MessageEmbed) {
+		t.Helper()
+		fields := eventFields(embed)
+		if fields["Character:"] != "Jack (rank 150)" {
+			t.Errorf("%s character = %q", command, fields["Character:"])
+		}
+		if !strings.Contains(fields["Role ranks:"], "Bountyhunter: 12") || !strings.Contains(fields["Role ranks:"], "Naturalist: 20") ||
+			strings.Contains(fields["Role ranks:"], "Trader") {
+			t.Errorf("%s role ranks = %q", command, fields["Role ranks:"])
+		}
+	}
+	check("/online", f.command(testUser, channel, "online").Message.Embeds[0])
+	check("/show", f.command(testUser, channel, "show").Message.Embeds[0])
+
+	// Profiles without a character show no empty fields
+	f.submit(testUser, channel, "set_character:"+testUser.ID+":"+pc.Name, map[string]string{"character": "", "rank": ""})
+	if fields := eventFields(f.command(testUser, channel, "show").Message.Embeds[0]); fields["Character:"] != "" {
+		t.Errorf("cleared character = %q", fields["Character:"])
+	}
+}
+
+func TestMemberProfile(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	ps := b.Config.Platforms[1]
+	channel := f.channelID(ps.Channel)
+
+	res := f.commandOptions(testUser, channel, "profile", map[string]any{"user": john})
+	if !strings.Contains(res.Message.Content, "<@"+john.ID+"> has not set up a profile yet") {
+		t.Errorf("/profile without setup = %q", res.Message.Content)
+	}
+
+	f.submit(john, channel, "setup:"+john.ID, map[string]string{"rockstar_id": "123456789", "bounty": "7", "footer": "Howdy"})
+	f.command(john, channel, "online")
+	res = f.commandOptions(testUser, channel, "profile", map[string]any{"user": john})
+	if len(res.Message.Embeds) != 1 {
+		t.Fatalf("/profile responded with %+v", res.Message)
+	}
+	embed := res.Message.Embeds[0]
+	fields := eventFields(embed)
+	if embed.Title != john.Username || fields["Bounty:"] != "$7.00" || fields["Platform:"] != ps.Label || fields["Last seen:"] != "Online now" || fields["Total playtime:"] != "-" {
+		t.Errorf("/profile of online member = %q %v", embed.Title, fields)
+	}
+	if embed.Footer.Text != "Howdy" || embed.Thumbnail.URL != b.Avatars.url("123456789") {
+		t.Errorf("/profile footer %q and avatar %q", embed.Footer.Text, embed.Thumbnail.URL)
+	}
+
+	// Last seen is the end of the session, not later activity like editing the profile
+	f.command(john, channel, "offline")
+	key := PlayerKey{Namespace: testGuildID, DiscordID: john.ID}
+	p, err := b.Players.(*memoryPlayerStore).update(key, false, func(p *Player) { p.Time = p.Time.Add(-time.Hour) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields = eventFields(f.commandOptions(testUser, channel, "profile", map[string]any{"user": john}).Message.Embeds[0])
+	if fields["Last seen:"] != timestamp(p.Time, "R") || fields["Total playtime:"] != "0m" {
+		t.Errorf("/profile of offline member = %v", fields)
+	}
+}
+
+// failingPlayerStore fails all profile changes.
+type failingPlayerStore struct {
+	PlayerStore
+}
+
+func (s failingPlayerStore) UpsertProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (bool, error) {
+	return false, errors.New("database down")
+}
+
+func (s failingPlayerStore) UpdateProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (*Player, error) {
+	return nil, errors.New("database down")
+}
+
+func TestProfileSaveErrors(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[0].Channel)
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	b.Players = failingPlayerStore{b.Players}
+
+	for name, res := range map[string]*fakeResponse{
+		"setup":   f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "5", "footer": ""}),
+		"bounty":  f.submit(testUser, channel, "set_bounty:"+testUser.ID, map[string]string{"bounty": "20"}),
+		"footer":  f.submit(testUser, channel, "set_footer:"+testUser.ID, map[string]string{"footer": "Hunting"}),
+		"camp":    f.click(testUser, channel, "camp_selection", b.Config.Camps[0]),
+		"id":      f.submit(testUser, channel, "set_rid:"+testUser.ID, map[string]string{"rockstar_id": "123456789"}),
+		"privacy": f.click(testUser, channel, "privacy:camp", string(VisibilityHidden)),
+	} {
+		if res.Message.Content != "Sorry, something went wrong. Please try again later." {
+			t.Errorf("%s with failing store = %q", name, res.Message.Content)
+		}
+	}
+	b.ErrorReport.Flush()
+	if reported := f.takeReportedErrors(); len(reported) != 6 {
+		t.Errorf("reported errors = %q, want 6", reported)
+	}
+}
+
+func TestMemoryPlayerStoreCopiesRoleRanks(t *testing.T) {
+	t.Parallel()
+	store := newMemoryPlayerStore()
+	ctx := context.Background()
+	key := PlayerKey{Namespace: testGuildID, DiscordID: testUser.ID}
+	ranks := map[string]int{"Trader": 20}
+	if _, err := store.UpsertProfile(ctx, key, ProfileUpdate{RoleRanks: ranks}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := store.UpdateProfile(ctx, key, ProfileUpdate{Platform: "PS4", RoleRanks: ranks})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the update or a returned player leaves the stored ranks alone
+	ranks["Trader"] = 1
+	p.Default.RoleRanks["Trader"] = 2
+	p.Profiles["PS4"].RoleRanks["Trader"] = 3
+	p, err = store.Player(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Default.RoleRanks["Trader"] != 20 || p.Profiles["PS4"].RoleRanks["Trader"] != 20 {
+		t.Errorf("stored ranks = %v, %v, want 20", p.Default.RoleRanks, p.Profiles["PS4"].RoleRanks)
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"log"
-
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -12,28 +10,24 @@ type serverRole struct {
 	Emoji string
 }
 
-var (
-	guildRoles = make(map[string]*serverRole)
-)
-
 func (b *Bot) userWelcome(s *discordgo.Session, u *discordgo.GuildMemberAdd) {
-	if len(u.Roles) == 0 {
-		_, err := b.Session.ChannelMessageSend(b.generalChannelID, "Howdy <@"+u.User.ID+">, welcome to the server!\nTo get you started please select your roles in <#"+b.rolesChannelID+"> and have a look inside <#"+b.commandsChannelID+">.")
+	g := b.guild(u.GuildID)
+	if g != nil && len(u.Roles) == 0 {
+		_, err := b.Session.ChannelMessageSend(g.Channels.General, "Howdy <@"+u.User.ID+">, welcome to the server!\nTo get you started please select your roles in <#"+g.Channels.Roles+"> and have a look inside <#"+g.Channels.Commands+">.")
 		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+			b.reportError(g.log, "Error sending message", err)
 		}
 	}
 }
 
 func (b *Bot) assignRole(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
-	if b.roleSelfAssignMessageID != "" && r.MessageID == b.roleSelfAssignMessageID && r.UserID != s.State.User.ID {
-		for _, role := range guildRoles {
+	g := b.guild(r.GuildID)
+	if g != nil && g.RolesMessageID != "" && r.MessageID == g.RolesMessageID && r.UserID != s.State.User.ID {
+		for _, role := range g.roles {
 			if r.Emoji.Name == role.Emoji {
-				err := b.Session.GuildMemberRoleAdd(b.GuildID, r.UserID, role.ID)
+				err := b.Session.GuildMemberRoleAdd(g.GuildID, r.UserID, role.ID)
 				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
+					b.reportError(g.log, "Error assigning role", err)
 				}
 			}
 		}
@@ -41,13 +35,13 @@ func (b *Bot) assignRole(s *discordgo.Session, r *discordgo.MessageReactionAdd)
 }
 
 func (b *Bot) unassignRole(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
-	if b.roleSelfAssignMessageID != "" && r.MessageID == b.roleSelfAssignMessageID && r.UserID != s.State.User.ID {
-		for _, role := range guildRoles {
+	g := b.guild(r.GuildID)
+	if g != nil && g.RolesMessageID != "" && r.MessageID == g.RolesMessageID && r.UserID != s.State.User.ID {
+		for _, role := range g.roles {
 			if r.Emoji.Name == role.Emoji {
-				err := b.Session.GuildMemberRoleRemove(b.GuildID, r.UserID, role.ID)
+				err := b.Session.GuildMemberRoleRemove(g.GuildID, r.UserID, role.ID)
 				if err != nil {
-					b.ErrorReport.Notify(err, nil)
-					log.Println(err)
+					b.reportError(g.log, "Error unassigning role", err)
 				}
 			}
 		}
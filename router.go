@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handlerFunc handles an interaction routed to it. Returned errors are
+// reported and answered with a generic error message.
+type handlerFunc func(b *Bot, g *guild, i *interaction) error
+
+// route matches custom IDs against a pattern of colon separated segments.
+// Segments in braces like {userID} match any non-empty value and are made
+// available as parameters.
+type route struct {
+	pattern  string
+	segments []string
+	handler  handlerFunc
+}
+
+type router struct {
+	commands   map[string]handlerFunc
+	components []route
+	modals     []route
+}
+
+func newRouter(commands, components, modals map[string]handlerFunc) *router {
+	r := &router{commands: commands}
+	for pattern, h := range components {
+		r.components = append(r.components, newRoute(pattern, h))
+	}
+	for pattern, h := range modals {
+		r.modals = append(r.modals, newRoute(pattern, h))
+	}
+	return r
+}
+
+func newRoute(pattern string, h handlerFunc) route {
+	segments := strings.Split(pattern, ":")
+	for _, s := range segments {
+		if s == "" || strings.Trim(s, "{}") == "" {
+			panic("invalid route pattern " + pattern)
+		}
+	}
+	return route{pattern: pattern, segments: segments, handler: h}
+}
+
+// match returns the parameters extracted from customID if it matches the route.
+func (r route) match(customID string) (map[string]string, bool) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != len(r.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for n, s := range r.segments {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			if parts[n] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = parts[n]
+		} else if s != parts[n] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// resolve finds the handler for the interaction and stores the matched route
+// name and parameters in it.
+func (r *router) resolve(i *interaction) (handlerFunc, bool) {
+	var routes []route
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		h, ok := r.commands[i.customID]
+		i.name = i.customID
+		return h, ok
+	case discordgo.InteractionMessageComponent:
+		routes = r.components
+	case discordgo.InteractionModalSubmit:
+		routes = r.modals
+	}
+
+	for _, route := range routes {
+		if params, ok := route.match(i.customID); ok {
+			i.name = route.pattern
+			i.params = params
+			return route.handler, true
+		}
+	}
+	return nil, false
+}
+
+// customID builds a custom ID matching a route from its segments.
+func customID(segments ...string) string {
+	return strings.Join(segments, ":")
+}
+
+// modalFields maps the text inputs of a submitted modal to their values.
+type modalFields map[string]string
+
+func parseModalFields(data discordgo.ModalSubmitInteractionData) (modalFields, error) {
+	fields := make(modalFields)
+	for n, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			return nil, fmt.Errorf("modal %s: component %d is a %T, not an action row", data.CustomID, n, c)
+		}
+		for _, rc := range row.Components {
+			input, ok := rc.(*discordgo.TextInput)
+			if !ok {
+				return nil, fmt.Errorf("modal %s: action row %d contains a %T, not a text input", data.CustomID, n, rc)
+			}
+			fields[input.CustomID] = input.Value
+		}
+	}
+	return fields, nil
+}
+
+// text returns the trimmed value of the text input with the given custom ID.
+func (f modalFields) text(customID string) (string, error) {
+	value, ok := f[customID]
+	if !ok {
+		return "", fmt.Errorf("modal has no text input %q", customID)
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sweepInterval is how often online players are checked for ended sessions.
+const sweepInterval = time.Minute
+
+func (c SessionConfig) enabled() bool {
+	return c.IdleTimeout > 0 || c.MaxLength > 0
+}
+
+// deadline returns when the session of p ends unless the player uses the bot
+// or confirms to be still playing before.
+func (c SessionConfig) deadline(p *Player) time.Time {
+	var deadline time.Time
+	if c.IdleTimeout > 0 {
+		deadline = p.Active.Add(c.IdleTimeout)
+	}
+	if c.MaxLength > 0 {
+		end := p.Confirmed.Add(c.MaxLength)
+		if deadline.IsZero() || end.Before(deadline) {
+			deadline = end
+		}
+	}
+	return deadline
+}
+
+// runSessionSweeper periodically flags players offline whose session ended
+// until ctx is cancelled.
+func (b *Bot) runSessionSweeper(ctx context.Context) {
+	if !b.Config.Sessions.enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.sweepSessions(ctx, now)
+		}
+	}
+}
+
+// sweepSessions flags players offline whose session ended by now and reminds
+// those whose session is about to end.
+func (b *Bot) sweepSessions(ctx context.Context, now time.Time) {
+	swept := make(map[string]bool)
+	for _, g := range b.readyGuilds() {
+		// Guilds sharing a namespace share their players, the first one announces them
+		if swept[g.Namespace] {
+			continue
+		}
+		swept[g.Namespace] = true
+
+		for _, platform := range b.Config.Platforms {
+			players, err := b.Players.OnlinePlayers(ctx, g.Namespace, platform.Name)
+			if err != nil {
+				b.reportError(g.log, "Error reading online players", err)
+				continue
+			}
+			for n := range players {
+				b.sweepSession(ctx, g, &players[n], now)
+			}
+		}
+	}
+}
+
+func (b *Bot) sweepSession(ctx context.Context, g *guild, p *Player, now time.Time) {
+	deadline := b.Config.Sessions.deadline(p)
+	reminder := b.Config.Sessions.Reminder
+	log := g.log.With("user_id", p.DiscordId)
+
+	if !now.Before(deadline) {
+		log.Info("Session ended, flagging player offline", "online_since", p.Time, "last_active", p.Active)
+		result, err := b.setOffline(ctx, g.player(p.DiscordId), log)
+		if err != nil {
+			if err != ErrPlayerNotFound {
+				b.reportError(log, "Error setting player offline", err)
+			}
+			return
+		}
+
+		channelID, ok := g.Channels.Platforms[result.Platform]
+		if !ok {
+			log.Warn("No channel for platform, skipping offline message", "platform", result.Platform)
+			return
+		}
+		_, err = b.Session.ChannelMessageSendEmbed(channelID, b.offlineEmbed(result))
+		if err != nil {
+			b.reportError(log, "Error sending message", err)
+		}
+	} else if reminder > 0 && !p.Reminded && !now.Before(deadline.Add(-reminder)) {
+		b.remindSession(ctx, g, p, deadline, log)
+	}
+}
+
+// setOffline flags the player as offline and records the session that ended.
+// It returns the player as it was before.
+func (b *Bot) setOffline(ctx context.Context, key PlayerKey, log *slog.Logger) (*Player, error) {
+	p, err := b.Players.SetOffline(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	b.refreshBoards(key.Namespace)
+
+	// The posse of a leader going offline breaks up
+	if p.Posse == p.DiscordId {
+		err = b.Players.DisbandPosse(ctx, key.Namespace, p.DiscordId)
+		if err != nil {
+			b.reportError(log, "Error disbanding posse", err)
+		}
+	}
+
+	b.recordSession(ctx, p, log)
+	return p, nil
+}
+
+// setOnline flags the player online on platform. A session already running
+// is recorded first, as it restarts now. Switching platforms also leaves
+// the posse like going offline does.
+func (b *Bot) setOnline(ctx context.Context, key PlayerKey, platform string, log *slog.Logger) (*Player, error) {
+	p, err := b.Players.Player(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if p.Online && p.Platform != platform {
+		_, err = b.setOffline(ctx, key, log)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		b.recordSession(ctx, p, log)
+	}
+	return b.Players.SetOnline(ctx, key, platform, false)
+}
+
+// recordSession records the session of p up to now if p is online.
+func (b *Bot) recordSession(ctx context.Context, p *Player, log *slog.Logger) {
+	if !p.Online || p.Time.IsZero() {
+		return
+	}
+	err := b.Sessions.RecordSession(ctx, &Session{
+		Namespace: p.Namespace,
+		DiscordId: p.DiscordId,
+		Platform:  p.Platform,
+		Camp:      p.Camp,
+		Bounty:    p.Bounty,
+		Start:     p.Time,
+		End:       time.Now(),
+	})
+	if err != nil {
+		b.reportError(log, "Error recording session", err)
+	}
+}
+
+// remindSession asks the player by DM whether they are still playing.
+func (b *Bot) remindSession(ctx context.Context, g *guild, p *Player, deadline time.Time, log *slog.Logger) {
+	// Only ask once per session, even if the player does not accept DMs
+	err := b.Players.SetReminded(ctx, g.player(p.DiscordId))
+	if err != nil {
+		b.reportError(log, "Error updating player", err)
+		return
+	}
+
+	log.Info("Asking player whether they are still playing")
+	channel, err := b.Session.UserChannelCreate(p.DiscordId)
+	if err == nil {
+		_, err = b.Session.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+			Content: "Still playing? You will be flagged offline <t:" + strconv.FormatInt(deadline.Unix(), 10) + ":R>.",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Still playing",
+							Style:    discordgo.SuccessButton,
+							CustomID: customID("still_playing", g.GuildID),
+						},
+					},
+				},
+			},
+		})
+	}
+	if err != nil {
+		// Players may not accept DMs, which is not worth reporting
+		log.Warn("Could not send DM", "err", err)
+	}
+}
+
+// stillPlaying extends the session of the player who pressed the button in
+// the reminder DM.
+func (b *Bot) stillPlaying(i *interaction) error {
+	g := b.guild(i.param("guildID"))
+	if g == nil {
+		return errGuildNotReady
+	}
+
+	content := "Have fun! Your online session has been extended."
+	_, err := b.Players.ExtendSession(context.TODO(), g.player(i.userID()))
+	if err == ErrPlayerNotFound {
+		content = "You are already offline. Use `/online` to go online again."
+	} else if err != nil {
+		return err
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSessionSweeper(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	b.Config.Sessions = SessionConfig{IdleTimeout: time.Hour, MaxLength: 4 * time.Hour, Reminder: 10 * time.Minute}
+	channel := f.channelID(b.Config.Platforms[0].Channel)
+
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	f.command(testUser, channel, "online")
+	ctx := context.Background()
+	start := time.Now()
+
+	b.sweepSessions(ctx, start.Add(30*time.Minute))
+	dm := f.dmChannelID(testUser.ID)
+	if messages := f.channelMessages(dm); len(messages) != 0 {
+		t.Fatalf("got reminder %q before the session was about to end", messages[0].Content)
+	}
+
+	b.sweepSessions(ctx, start.Add(55*time.Minute))
+	b.sweepSessions(ctx, start.Add(56*time.Minute))
+	messages := f.channelMessages(dm)
+	if len(messages) != 1 || !strings.HasPrefix(messages[0].Content, "Still playing?") {
+		t.Fatalf("got DMs %+v, want one reminder", messages)
+	}
+
+	res := f.click(testUser, dm, customID("still_playing", testGuildID))
+	if res.Type != discordgo.InteractionResponseUpdateMessage || !strings.Contains(res.Message.Content, "extended") {
+		t.Errorf("still playing response = %+v", res.Message)
+	}
+
+	p, err := b.Players.Player(ctx, b.guild(testGuildID).player(testUser.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Reminded || p.Confirmed.Before(p.Time) {
+		t.Errorf("session not extended: online since %v, confirmed %v, reminded %v", p.Time, p.Confirmed, p.Reminded)
+	}
+
+	b.sweepSessions(ctx, start.Add(2*time.Hour))
+	// The status board comes first
+	messages = f.channelMessages(channel)
+	if len(messages) != 2 || messages[1].Embeds[0].Title != testUser.Username+" is now offline." {
+		t.Fatalf("got %+v in platform channel, want offline message", messages)
+	}
+	res = f.command(testUser, channel, "show")
+	if res.Message.Embeds[0].Description != "There are no players online at the moment." {
+		t.Errorf("/show after session ended responded with %+v", res.Message.Embeds)
+	}
+
+	res = f.click(testUser, dm, customID("still_playing", testGuildID))
+	if !strings.Contains(res.Message.Content, "already offline") {
+		t.Errorf("still playing after going offline = %q", res.Message.Content)
+	}
+}
+
+func TestSessionDeadline(t *testing.T) {
+	start := time.Date(2023, 4, 1, 18, 0, 0, 0, time.UTC)
+	p := &Player{Time: start, Confirmed: start, Active: start.Add(3 * time.Hour)}
+
+	tests := []struct {
+		config SessionConfig
+		want   time.Time
+	}{
+		{SessionConfig{IdleTimeout: time.Hour}, start.Add(4 * time.Hour)},
+		{SessionConfig{MaxLength: 2 * time.Hour}, start.Add(2 * time.Hour)},
+		{SessionConfig{IdleTimeout: time.Hour, MaxLength: 8 * time.Hour}, start.Add(4 * time.Hour)},
+		{SessionConfig{IdleTimeout: time.Hour, MaxLength: 3 * time.Hour}, start.Add(3 * time.Hour)},
+	}
+	for _, test := range tests {
+		if got := test.config.deadline(p); !got.Equal(test.want) {
+			t.Errorf("%+v: deadline = %v, want %v", test.config, got, test.want)
+		}
+	}
+}
+
+func TestSwitchPlatforms(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	ctx := context.Background()
+	key := b.guild(testGuildID).player(testUser.ID)
+	first, second := b.Config.Platforms[0], b.Config.Platforms[1]
+	played := func() {
+		t.Helper()
+		_, err := b.Players.(*memoryPlayerStore).update(key, false, func(p *Player) { p.Time = p.Time.Add(-time.Hour) })
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.submit(testUser, f.channelID(first.Channel), "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	f.command(testUser, f.channelID(first.Channel), "online")
+	played()
+
+	// Going online on another platform and again on the same one keeps the
+	// playtime so far
+	res := f.command(testUser, f.channelID(second.Channel), "online")
+	if len(res.Message.Embeds) != 1 || res.Message.Embeds[0].Title != testUser.Username+" is now online." {
+		t.Fatalf("/online on another platform responded with %+v", res.Message)
+	}
+	played()
+	f.command(testUser, f.channelID(second.Channel), "online")
+
+	sessions, err := b.Sessions.Sessions(ctx, testGuildID, testUser.ID, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("recorded %d sessions, want 2", len(sessions))
+	}
+	for n, platform := range []string{first.Name, second.Name} {
+		s := sessions[n]
+		if s.Platform != platform || s.End.Sub(s.Start) < time.Hour {
+			t.Errorf("session %d = %s for %v, want %s for an hour", n, s.Platform, s.End.Sub(s.Start), platform)
+		}
+	}
+
+	p, err := b.Players.Player(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Online || p.Platform != second.Name || time.Since(p.Time) > time.Minute {
+		t.Errorf("player online %v on %s since %v, want a new session on %s", p.Online, p.Platform, p.Time, second.Name)
+	}
+}
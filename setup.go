@@ -2,12 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/microcosm-cc/bluemonday"
@@ -15,82 +16,163 @@ import (
 	"github.com/yuin/goldmark/parser"
 )
 
-var (
-	roleEmojis = make(map[string]string)
-)
-
 func (b *Bot) prepareServer(s *discordgo.Session, m *discordgo.Ready) {
-	b.getChannelIDs()
-	b.setupRoles()
-	b.setupCommands()
-	b.updateChangelog()
-	log.Println("Initial setup complete. Bot is now ready and waiting...")
-	fmt.Println("================================================================================")
+	for _, g := range m.Guilds {
+		b.prepareGuild(g.ID)
+	}
+	b.prepared.Store(true)
+	slog.Info("Initial setup complete. Bot is now ready and waiting")
+}
+
+// prepareGuild sets up a server once, no matter how often it is announced by
+// Ready and GuildCreate events.
+func (b *Bot) prepareGuild(guildID string) {
+	if !b.claimGuild(guildID) {
+		return
+	}
+
+	g := b.loadGuild(guildID)
+	g.log.Info("Preparing server")
+	b.getChannelIDs(g)
+	// The gateway is locked while the Ready event is handled
+	go b.requestMembers(g)
+	b.setupRoles(g)
+	b.setupStatusBoards(g)
+	b.setupCommands(g)
+	b.updateChangelog(g)
+
+	err := b.Guilds.SaveGuildConfig(context.TODO(), &g.GuildConfig)
+	if err != nil {
+		b.reportError(g.log, "Error saving server config", err)
+	}
+	b.storeGuild(g)
 }
 
-func (b *Bot) getChannelIDs() {
-	log.Println("Reading channels...")
-	channels, err := b.Session.GuildChannels(b.GuildID)
+func (b *Bot) loadGuild(guildID string) *guild {
+	g := &guild{
+		GuildConfig: GuildConfig{GuildID: guildID, Namespace: guildID},
+		roles:       make(map[string]*serverRole),
+		commandIDs:  make(map[string]string),
+		log:         slog.With("guild_id", guildID),
+	}
+
+	config, err := b.Guilds.GuildConfig(context.TODO(), guildID)
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		if err != ErrGuildNotFound {
+			b.reportError(g.log, "Error reading server config", err)
+		}
+	} else {
+		g.GuildConfig = *config
+	}
+	if g.Channels.Platforms == nil {
+		g.Channels.Platforms = make(map[string]string)
+	}
+	if g.BoardMessageIDs == nil {
+		g.BoardMessageIDs = make(map[string]string)
+	}
+
+	return g
+}
+
+// requestMembers asks the gateway for all members of the server to fill the
+// member cache of the session state, which member events keep up to date.
+func (b *Bot) requestMembers(g *guild) {
+	g.log.Info("Requesting members")
+	err := b.Session.RequestGuildMembers(g.GuildID, "", 0, "", false)
+	if err != nil {
+		b.reportError(g.log, "Error requesting members", err)
+	}
+}
+
+func (b *Bot) getChannelIDs(g *guild) {
+	g.log.Info("Reading channels")
+	channels, err := b.Session.GuildChannels(g.GuildID)
+	if err != nil {
+		b.reportError(g.log, "Error reading channels", err)
+		return
+	}
+
+	// Forget channels that no longer exist, configured channels take precedence otherwise
+	existing := make(map[string]bool)
+	for _, c := range channels {
+		existing[c.ID] = true
+	}
+	for _, id := range []*string{&g.Channels.General, &g.Channels.Roles, &g.Channels.Commands, &g.Channels.Bulletin} {
+		if !existing[*id] {
+			*id = ""
+		}
+	}
+	for platform, id := range g.Channels.Platforms {
+		if !existing[id] {
+			delete(g.Channels.Platforms, platform)
+		}
 	}
 
 	for _, c := range channels {
 		switch c.Name {
-		case "general":
-			b.generalChannelID = c.ID
-		case "roles":
-			b.rolesChannelID = c.ID
-		case "commands":
-			b.commandsChannelID = c.ID
-		case "bulletin":
-			b.bulletinChannelID = c.ID
-		case "pc":
-			b.pcChannelID = c.ID
-		case "ps4":
-			b.playstationChannelID = c.ID
-		case "xbox-one":
-			b.xboxChannelID = c.ID
+		case b.Config.Channels.General:
+			setChannelID(&g.Channels.General, c.ID)
+		case b.Config.Channels.Roles:
+			setChannelID(&g.Channels.Roles, c.ID)
+		case b.Config.Channels.Commands:
+			setChannelID(&g.Channels.Commands, c.ID)
+		case b.Config.Channels.Bulletin:
+			setChannelID(&g.Channels.Bulletin, c.ID)
+		default:
+			for _, p := range b.Config.Platforms {
+				if _, ok := g.Channels.Platforms[p.Name]; !ok && c.Name == p.Channel {
+					g.Channels.Platforms[p.Name] = c.ID
+				}
+			}
 		}
 	}
 }
 
-func (b *Bot) setupRoles() {
-	//Emojis need to be manually assigned for free servers
-	roleEmojis["Bountyhunter"] = "⛓"
-	roleEmojis["Trader"] = "🤝"
-	roleEmojis["Collector"] = "🔮"
-	roleEmojis["Moonshiner"] = "🥃"
-	roleEmojis["Naturalist"] = "🌿"
-	roleEmojis["PC"] = "💻"
-	roleEmojis["PS4"] = "🅿"
-	roleEmojis["XBOX"] = "❎"
-
-	roleSelfAssignDescription := "React to this message to assign your roles:\n\n⛓ Bountyhunter \n\n🤝 Trader\n\n🔮 Collector\n\n🥃 Moonshiner\n\n🌿 Naturalist\n\n💻 PC\n\n🅿 Playstation\n\n❎ Xbox"
-
-	log.Println("Reading server roles...")
-	roles, err := b.Session.GuildRoles(b.GuildID)
+func setChannelID(id *string, channelID string) {
+	if *id == "" {
+		*id = channelID
+	}
+}
+
+func (b *Bot) setupRoles(g *guild) {
+	roleSelfAssignDescription := b.Config.roleDescription()
+
+	g.log.Info("Reading server roles")
+	roles, err := b.Session.GuildRoles(g.GuildID)
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		b.reportError(g.log, "Error reading server roles", err)
 	}
 
 	for _, r := range roles {
-		// Skipping @everyone & bot/application role
-		if r.Name == "@everyone" || r.Name == b.BotRole {
+		// Skipping @everyone, bot/application role and roles without an emoji
+		emoji := b.Config.roleEmoji(r.Name)
+		if r.Name == "@everyone" || r.Name == b.BotRole || emoji == "" {
 			continue
 		}
 
 		// Store roles in map for self assignment
-		guildRoles[r.Name] = &serverRole{ID: r.ID, Name: r.Name, Emoji: roleEmojis[r.Name]}
+		g.roles[r.Name] = &serverRole{ID: r.ID, Name: r.Name, Emoji: emoji}
 	}
 
-	log.Println("Reading roles channel messages...")
-	rolesChannelMessages, err := b.Session.ChannelMessages(b.rolesChannelID, 10, "", "", "")
-	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+	if g.Channels.Roles == "" {
+		g.log.Warn("No roles channel found, skipping role selfassignment message")
+		return
+	}
+
+	// Prefer the stored message, fall back to the latest message in the channel
+	var roleMessage *discordgo.Message
+	if g.RolesMessageID != "" {
+		roleMessage, _ = b.Session.ChannelMessage(g.Channels.Roles, g.RolesMessageID)
+	}
+	if roleMessage == nil {
+		g.log.Info("Reading roles channel messages")
+		rolesChannelMessages, err := b.Session.ChannelMessages(g.Channels.Roles, 10, "", "", "")
+		if err != nil {
+			b.reportError(g.log, "Error reading channel messages", err)
+		}
+		if len(rolesChannelMessages) > 0 {
+			roleMessage = rolesChannelMessages[0]
+		}
 	}
 
 	roleMessageEmbed := &discordgo.MessageEmbed{
@@ -100,162 +182,276 @@ func (b *Bot) setupRoles() {
 		Color:       colorWhite,
 	}
 
-	if len(rolesChannelMessages) == 0 {
-		log.Println("Writing role selfassignment message...")
-		roleMessage, err := b.Session.ChannelMessageSendEmbed(b.rolesChannelID, roleMessageEmbed)
+	if roleMessage == nil {
+		g.log.Info("Writing role selfassignment message")
+		roleMessage, err = b.Session.ChannelMessageSendEmbed(g.Channels.Roles, roleMessageEmbed)
 		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+			b.reportError(g.log, "Error sending message", err)
+			return
 		}
 
 		// Assign bot to all roles to provide emoji-reactions
-		for _, role := range guildRoles {
-			err = b.Session.MessageReactionAdd(b.rolesChannelID, roleMessage.ID, role.Emoji)
+		for _, role := range g.roles {
+			err = b.Session.MessageReactionAdd(g.Channels.Roles, roleMessage.ID, role.Emoji)
 			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
+				b.reportError(g.log, "Error adding reaction", err)
 			}
 
-			log.Printf("Assign bot to role: %s", role.Name)
-			err = b.Session.GuildMemberRoleAdd(b.GuildID, b.Session.State.User.ID, role.ID)
+			g.log.Info("Assign bot to role", "role", role.Name)
+			err = b.Session.GuildMemberRoleAdd(g.GuildID, b.Session.State.User.ID, role.ID)
 			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
+				b.reportError(g.log, "Error assigning role", err)
 			}
 		}
-		b.roleSelfAssignMessageID = roleMessage.ID
-	} else if rolesChannelMessages[0].Embeds[0].Description != roleSelfAssignDescription {
-		log.Println("Updating role selfassignment message...")
-		roleMessage, err := b.Session.ChannelMessageEditEmbed(b.rolesChannelID, rolesChannelMessages[0].ID, roleMessageEmbed)
+	} else if len(roleMessage.Embeds) == 0 || roleMessage.Embeds[0].Description != roleSelfAssignDescription {
+		g.log.Info("Updating role selfassignment message")
+		_, err := b.Session.ChannelMessageEditEmbed(g.Channels.Roles, roleMessage.ID, roleMessageEmbed)
 		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+			b.reportError(g.log, "Error editing message", err)
 		}
-		b.roleSelfAssignMessageID = roleMessage.ID
-	} else {
-		b.roleSelfAssignMessageID = rolesChannelMessages[0].ID
 	}
+	g.RolesMessageID = roleMessage.ID
+}
+
+// guildCommands returns the commands to register with the options that
+// depend on the config filled in.
+func guildCommands(c *Config) []*discordgo.ApplicationCommand {
+	list := make([]*discordgo.ApplicationCommand, 0, len(commands)+1)
+	for _, cmd := range commands {
+		if cmd.Name == "setup" || cmd.Name == "me" {
+			withOption := *cmd
+			withOption.Options = []*discordgo.ApplicationCommandOption{platformOption(c)}
+			cmd = &withOption
+		}
+		list = append(list, cmd)
+	}
+	return append(list, lfgCommand(c))
 }
 
-func (b *Bot) setupCommands() {
-	log.Println("Updating server commands...")
-	registeredCommands, err := b.Session.ApplicationCommandBulkOverwrite(b.Session.State.User.ID, b.GuildID, commands)
+func (b *Bot) setupCommands(g *guild) {
+	g.log.Info("Updating server commands")
+	registeredCommands, err := b.Session.ApplicationCommandBulkOverwrite(b.Session.State.User.ID, g.GuildID, guildCommands(b.Config))
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		b.reportError(g.log, "Error registering commands", err)
 	}
 	for _, cmd := range registeredCommands {
-		switch cmd.Name {
-		case "setup":
-			b.setupCommandID = cmd.ID
-		case "me":
-			b.meCommandID = cmd.ID
-		case "online":
-			b.onlineCommandID = cmd.ID
-		case "offline":
-			b.offlineCommandID = cmd.ID
-		case "show":
-			b.showPlayersCommandID = cmd.ID
-		}
+		g.commandIDs[cmd.Name] = cmd.ID
 	}
 
-	log.Println("Reading commands channel messages...")
-	commandsChannelMessages, err := b.Session.ChannelMessages(b.commandsChannelID, 10, "", "", "")
-	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+	if g.Channels.Commands == "" {
+		g.log.Warn("No commands channel found, skipping command instructions")
+		return
 	}
 
-	commandMessageContent := "</setup:" + b.setupCommandID + "> : Set up your RDO profile for the server. Here you can set your R* ID for the Avatar, your camp location, bounty and a message that displays in the footer region in your online notification.\nTo find your R* ID, visit your Social Club profile here: <https://socialclub.rockstargames.com/games/rdr2/overview>.\nOn the tiny avatar of your character do a right-click and click on *Open image in new tab*. In the browser address bar you will notice a 9-digit number (just before */pedshot_0.jpg*). This is your R* ID which you can enter during setup to have your avatar displayed in online notifications.\n`/setup` is a convenient way to provide all info at once.\n\n</me:" + b.meCommandID + "> : This command displays your current profile information along with buttons for editing. It is a quick way to check and update your info.\n\n</online:" + b.onlineCommandID + "> : Flag yourself as online to let others know you are ingame.\nThe bot will respond with a message providing you with a couple of buttons for quickly editing your information during your gameplay.\nUse it in the channel of your platform (or lobby).\n\n</offline:" + b.offlineCommandID + "> : Flag yourself as offline to let others know you are not ingame anymore.\nUse it in the same channel where you flagged yourself as online.\n\n</show:" + b.showPlayersCommandID + "> : Show players that are online with their current data."
+	b.syncInstructions(g, splitMessage(commandInstructions(g), maxMessageLength))
+}
 
-	if len(commandsChannelMessages) == 0 {
-		log.Println("Adding command instructions...")
-		_, err := b.Session.ChannelMessageSend(b.commandsChannelID, commandMessageContent)
-		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+// maxMessageLength is the most characters Discord allows in a message.
+const maxMessageLength = 2000
+
+// commandInstructions are the paragraphs of the command instructions.
+func commandInstructions(g *guild) []string {
+	return []string{
+		g.commandMention("setup") + " : Set up your RDO profile for the server. Here you can set your R* ID for the Avatar, your camp location, bounty and a message that displays in the footer region in your online notification.\nTo find your R* ID, visit your Social Club profile here: <https://socialclub.rockstargames.com/games/rdr2/overview>.\nOn the tiny avatar of your character do a right-click and click on *Open image in new tab*. In the browser address bar you will notice a 9-digit number (just before */pedshot_0.jpg*). This is your R* ID which you can enter during setup to have your avatar displayed in online notifications.\n`/setup` is a convenient way to provide all info at once.\nYou have a separate profile on each platform. Both commands use the one of the channel or your last platform, pick another one with the platform option.",
+		g.commandMention("me") + " : This command displays your current profile information along with buttons for editing. It is a quick way to check and update your info. Its *Privacy* button lets you choose who can see your camp, bounty, R* avatar and footer.",
+		g.commandMention("profile") + " : Show the profile of another member with their platform, when they were last seen and their total playtime.",
+		g.commandMention("online") + " : Flag yourself as online to let others know you are ingame.\nThe bot will respond with a message providing you with a couple of buttons for quickly editing your information during your gameplay.\nUse it in the channel of your platform (or lobby).",
+		g.commandMention("offline") + " : Flag yourself as offline to let others know you are not ingame anymore.\nUse it in the same channel where you flagged yourself as online.",
+		g.commandMention("show") + " : Show players that are online with their current data.",
+		g.commandMention("stats") + " : Show your total playtime, number of sessions and favourite platform and camp of the last 7 or 30 days or all time.",
+		g.commandMention("leaderboard") + " : Show who played the most, most often, on the most consecutive days or had the highest bounty.",
+		g.commandMention("auto-online") + " : Go online and offline automatically while Discord shows you playing Red Dead Redemption 2, on the platform you last went online on.",
+		g.commandMention("event create") + " : Schedule a group session with a title, start, activity, platform and number of slots. Others sign up with the buttons below the event and get a DM 30 and 5 minutes before it starts.",
+		g.commandMention("lfg") + " : Look for players for an activity in the channel of your platform, pinging everyone with the role of the activity or only those online on your platform. The group closes when it is full or after an hour.",
+		g.commandMention("watch add") + " : Get a DM whenever a player goes online. See your watchlist with " + g.commandMention("watch list") + ", remove players with " + g.commandMention("watch remove") + " and pause the DMs for a while with " + g.commandMention("watch mute") + ".",
+		g.commandMention("posse create") + " : Start a posse while you are online and add players online on your platform with " + g.commandMention("posse invite") + ". " + g.commandMention("show") + " lists a posse together. Members leave with " + g.commandMention("posse leave") + " or by going offline, the leader disbands it with " + g.commandMention("posse disband") + ".",
+	}
+}
+
+// splitMessage joins paragraphs into as few messages of at most max
+// characters as possible.
+func splitMessage(paragraphs []string, max int) []string {
+	var messages []string
+	current := ""
+	for _, p := range paragraphs {
+		if current != "" && utf8.RuneCountInString(current)+2+utf8.RuneCountInString(p) > max {
+			messages = append(messages, current)
+			current = ""
 		}
-	} else if commandsChannelMessages[0].Content != commandMessageContent {
-		log.Println("Updating command instructions...")
-		_, err := b.Session.ChannelMessageEdit(b.commandsChannelID, commandsChannelMessages[0].ID, commandMessageContent)
-		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+		if current != "" {
+			current += "\n\n"
 		}
+		current += p
 	}
+	if current != "" {
+		messages = append(messages, current)
+	}
+	return messages
 }
 
-func (b *Bot) updateChangelog() {
-	var changelogFile *os.File
-	var parsedChangelog bytes.Buffer
-
-	log.Println("Reading changelog messages...")
-	changelogMessages, err := b.Session.ChannelMessages(b.bulletinChannelID, 100, "", "", "")
-	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+// syncInstructions makes the instruction messages in the commands channel
+// match contents, reusing the ones from a previous run. Only messages known
+// to hold instructions are edited or removed.
+func (b *Bot) syncInstructions(g *guild, contents []string) {
+	// Prefer the stored messages, fall back to the single instructions
+	// message of the bot from before they were stored
+	var existing []*discordgo.Message
+	missing := false
+	for _, id := range g.CommandsMessageIDs {
+		m, err := b.Session.ChannelMessage(g.Channels.Commands, id)
+		if err != nil {
+			missing = true
+			continue
+		}
+		existing = append(existing, m)
+	}
+	if missing {
+		// Keep the order by posting all of them again
+		g.log.Info("Command instructions missing, posting them again")
+		b.deleteInstructions(g, existing)
+		existing = nil
+	}
+	if len(g.CommandsMessageIDs) == 0 {
+		g.log.Info("Reading commands channel messages")
+		messages, err := b.Session.ChannelMessages(g.Channels.Commands, 10, "", "", "")
+		if err != nil {
+			b.reportError(g.log, "Error reading channel messages", err)
+			return
+		}
+		for _, m := range messages {
+			if m.Author != nil && m.Author.ID == b.Session.State.User.ID && strings.HasPrefix(m.Content, "</setup:") {
+				existing = []*discordgo.Message{m}
+				break
+			}
+		}
 	}
 
-	if len(changelogMessages) > 0 {
-		for _, m := range changelogMessages {
-			err := b.Session.ChannelMessageDelete(b.bulletinChannelID, m.ID)
+	g.CommandsMessageIDs = nil
+	for n, content := range contents {
+		if n >= len(existing) {
+			g.log.Info("Adding command instructions")
+			m, err := b.Session.ChannelMessageSend(g.Channels.Commands, content)
 			if err != nil {
-				b.ErrorReport.Notify(err, nil)
-				log.Println(err)
+				b.reportError(g.log, "Error sending message", err)
+				continue
 			}
+			g.CommandsMessageIDs = append(g.CommandsMessageIDs, m.ID)
+			continue
 		}
+		if existing[n].Content != content {
+			g.log.Info("Updating command instructions")
+			_, err := b.Session.ChannelMessageEdit(g.Channels.Commands, existing[n].ID, content)
+			if err != nil {
+				b.reportError(g.log, "Error editing message", err)
+			}
+		}
+		g.CommandsMessageIDs = append(g.CommandsMessageIDs, existing[n].ID)
+	}
+	if len(existing) > len(contents) {
+		b.deleteInstructions(g, existing[len(contents):])
 	}
+}
 
-	log.Println("Getting current changelogs...")
-	res, err := http.Get(b.ChangelogURL)
+// deleteInstructions removes instruction messages of the bot.
+func (b *Bot) deleteInstructions(g *guild, messages []*discordgo.Message) {
+	for _, m := range messages {
+		g.log.Info("Removing outdated command instructions", "message_id", m.ID)
+		err := b.Session.ChannelMessageDelete(g.Channels.Commands, m.ID)
+		if err != nil {
+			b.reportError(g.log, "Error deleting message", err)
+		}
+	}
+}
+
+// updateChangelog replaces the messages in the bulletin channel with the
+// current changelog.
+func (b *Bot) updateChangelog(g *guild) {
+	if g.Channels.Bulletin == "" {
+		g.log.Warn("No bulletin channel found, skipping changelogs")
+		return
+	}
+
+	g.log.Info("Getting current changelogs")
+	changelogs, err := b.changelogs()
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		b.reportError(g.log, "Error getting changelog", err)
+		return
 	}
-	defer res.Body.Close()
 
-	if _, err := os.Stat("updates.md"); err == nil {
-		err := os.Remove("updates.md")
+	g.log.Info("Reading changelog messages")
+	changelogMessages, err := b.Session.ChannelMessages(g.Channels.Bulletin, 100, "", "", "")
+	if err != nil {
+		b.reportError(g.log, "Error reading channel messages", err)
+		return
+	}
+	for _, m := range changelogMessages {
+		err := b.Session.ChannelMessageDelete(g.Channels.Bulletin, m.ID)
 		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
+			b.reportError(g.log, "Error deleting message", err)
 		}
 	}
 
-	changelogFile, err = os.Create("updates.md")
-	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+	g.log.Info("Writing changelogs")
+	for _, c := range changelogs {
+		_, err = b.Session.ChannelMessageSend(g.Channels.Bulletin, c)
+		if err != nil {
+			b.reportError(g.log, "Error sending message", err)
+		}
+	}
+}
+
+// changelogs returns the messages of the changelog, which is downloaded once
+// for all servers.
+func (b *Bot) changelogs() ([]string, error) {
+	b.changelogMu.Lock()
+	defer b.changelogMu.Unlock()
+	if b.changelog != nil {
+		return b.changelog, nil
 	}
-	defer changelogFile.Close()
 
-	_, err = io.Copy(changelogFile, res.Body)
+	res, err := http.Get(b.ChangelogURL)
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading changelog: %s", res.Status)
+	}
+	source, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
 	}
 
-	source, err := os.ReadFile("updates.md")
+	changelogs, err := parseChangelog(source)
 	if err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		return nil, err
 	}
+	b.changelog = changelogs
+	return changelogs, nil
+}
 
+// parseChangelog converts the markdown changelog to one message per release.
+// Releases are separated by a paragraph holding a single dot.
+func parseChangelog(source []byte) ([]string, error) {
+	var parsedChangelog bytes.Buffer
 	if err := goldmark.New(goldmark.WithParserOptions(parser.WithBlockParsers())).Convert(source, &parsedChangelog); err != nil {
-		b.ErrorReport.Notify(err, nil)
-		log.Println(err)
+		return nil, err
 	}
 
 	changelogContent := strings.Trim(strings.ReplaceAll(parsedChangelog.String(), "<h1>Change Log</h1>\n", ""), " ")
-	changelogs := strings.Split(changelogContent, "<p>.</p>")
-
 	p := bluemonday.StripTagsPolicy()
 
-	log.Println("Writing changelogs...")
-	for _, c := range changelogs {
+	changelogs := []string{}
+	for _, c := range strings.Split(changelogContent, "<p>.</p>") {
 		htmlContent := strings.TrimSpace(c)
 		extractTitle := strings.Split(htmlContent, "</h2>")
+		if len(extractTitle) < 2 {
+			return nil, fmt.Errorf("changelog entry without title: %q", htmlContent)
+		}
 		extractChanges := strings.Split(extractTitle[1], "<h3>")
+		if len(extractChanges) < 4 {
+			return nil, fmt.Errorf("changelog entry without added, changed and fixed sections: %q", htmlContent)
+		}
 		extractAdded := strings.TrimSpace(extractChanges[1])
 		extractChanged := strings.TrimSpace(extractChanges[2])
 		extractFixed := strings.TrimSpace(extractChanges[3])
@@ -269,11 +465,7 @@ func (b *Bot) updateChangelog() {
 		changelogString += "```\n" + sanitizedAdded + "```"
 		changelogString += "```\n" + sanitizedChanged + "```"
 		changelogString += "```\n" + sanitizedFixed + "```\n"
-
-		_, err = b.Session.ChannelMessageSend(b.bulletinChannelID, changelogString)
-		if err != nil {
-			b.ErrorReport.Notify(err, nil)
-			log.Println(err)
-		}
+		changelogs = append(changelogs, changelogString)
 	}
+	return changelogs, nil
 }
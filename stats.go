@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// statsPeriod is a time window statistics can be shown for.
+type statsPeriod struct {
+	Name   string
+	Label  string
+	Length time.Duration
+}
+
+var statsPeriods = []statsPeriod{
+	{Name: "7d", Label: "the last 7 days", Length: 7 * 24 * time.Hour},
+	{Name: "30d", Label: "the last 30 days", Length: 30 * 24 * time.Hour},
+	{Name: "all", Label: "all time"},
+}
+
+const defaultStatsPeriod = "30d"
+
+func periodChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, p := range statsPeriods {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: p.Label, Value: p.Name})
+	}
+	return choices
+}
+
+// findPeriod returns the period with the given name, falling back to the default.
+func findPeriod(name string) statsPeriod {
+	if name == "" {
+		name = defaultStatsPeriod
+	}
+	for _, p := range statsPeriods {
+		if p.Name == name {
+			return p
+		}
+	}
+	return findPeriod(defaultStatsPeriod)
+}
+
+// since returns the start of the period ending at now, zero for all time.
+func (p statsPeriod) since(now time.Time) time.Time {
+	if p.Length == 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.Length)
+}
+
+// playerStats summarizes the sessions of a player.
+type playerStats struct {
+	Playtime time.Duration
+	Sessions int
+	// Platform and Camp are the ones the player spent the most time on.
+	Platform string
+	Camp     string
+}
+
+// summarizeSessions sums up sessions, counting only the time after since.
+func summarizeSessions(sessions []Session, since time.Time) playerStats {
+	var stats playerStats
+	platforms := make(map[string]time.Duration)
+	camps := make(map[string]time.Duration)
+
+	for _, s := range sessions {
+		start := s.Start
+		if start.Before(since) {
+			start = since
+		}
+		d := s.End.Sub(start)
+		if d < 0 {
+			continue
+		}
+
+		stats.Playtime += d
+		stats.Sessions++
+		platforms[s.Platform] += d
+		if s.Camp != "" {
+			camps[s.Camp] += d
+		}
+	}
+
+	stats.Platform = favourite(platforms)
+	stats.Camp = favourite(camps)
+	return stats
+}
+
+func (s playerStats) average() time.Duration {
+	if s.Sessions == 0 {
+		return 0
+	}
+	return s.Playtime / time.Duration(s.Sessions)
+}
+
+// favourite returns the key with the longest time, preferring the first in
+// alphabetical order on ties.
+func favourite(times map[string]time.Duration) string {
+	keys := make([]string, 0, len(times))
+	for k := range times {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fav string
+	for _, k := range keys {
+		if fav == "" || times[k] > times[fav] {
+			fav = k
+		}
+	}
+	return fav
+}
+
+// formatDuration formats d in hours and minutes, like 12h 05m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %02dm", h, m)
+}
+
+func (b *Bot) showStats(g *guild, i *interaction) error {
+	period := findPeriod(i.option("period"))
+	since := period.since(time.Now())
+
+	sessions, err := b.Sessions.Sessions(context.TODO(), g.Namespace, i.Member.User.ID, since)
+	if err != nil {
+		return err
+	}
+	stats := summarizeSessions(sessions, since)
+
+	embed := &discordgo.MessageEmbed{
+		Type:  discordgo.EmbedTypeRich,
+		Color: colorBlurple,
+		Title: "Your stats for " + period.Label,
+	}
+	if stats.Sessions == 0 {
+		embed.Description = "No sessions recorded for " + period.Label + ". Use `/online` and `/offline` to track your playtime."
+	} else {
+		camp := "-"
+		if stats.Camp != "" {
+			camp = stats.Camp
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Playtime:", Value: formatDuration(stats.Playtime), Inline: true},
+			{Name: "Sessions:", Value: strconv.Itoa(stats.Sessions), Inline: true},
+			{Name: "Average session:", Value: formatDuration(stats.average()), Inline: true},
+			{Name: "Favourite platform:", Value: b.Config.platformLabel(stats.Platform), Inline: true},
+			{Name: "Favourite camp:", Value: camp, Inline: true},
+		}
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
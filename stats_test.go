@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarizeSessions(t *testing.T) {
+	now := time.Date(2023, 4, 30, 20, 0, 0, 0, time.UTC)
+	since := now.Add(-7 * 24 * time.Hour)
+	sessions := []Session{
+		// Started before the window, only the last hour counts
+		{Platform: "PC", Camp: "Big Valley", Start: since.Add(-time.Hour), End: since.Add(time.Hour)},
+		{Platform: "PS4", Camp: "Grizzlies", Start: now.Add(-5 * time.Hour), End: now.Add(-2 * time.Hour)},
+		{Platform: "PC", Camp: "Big Valley", Start: now.Add(-time.Hour), End: now},
+	}
+
+	stats := summarizeSessions(sessions, since)
+	want := playerStats{Playtime: 5 * time.Hour, Sessions: 3, Platform: "PS4", Camp: "Grizzlies"}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if got := stats.average(); got != 100*time.Minute {
+		t.Errorf("average = %v, want 1h40m", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                              "0m",
+		59 * time.Second:               "1m",
+		45 * time.Minute:               "45m",
+		12*time.Hour + 5*time.Minute:   "12h 05m",
+		130*time.Hour + 59*time.Minute: "130h 59m",
+	} {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[1].Channel)
+
+	res := f.command(testUser, channel, "stats")
+	if !strings.HasPrefix(res.Message.Embeds[0].Description, "No sessions recorded") {
+		t.Errorf("/stats without sessions = %+v", res.Message.Embeds[0])
+	}
+
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "", "footer": ""})
+	f.click(testUser, channel, "camp_selection", b.Config.Camps[2])
+	for n := 0; n < 2; n++ {
+		f.command(testUser, channel, "online")
+		f.command(testUser, channel, "offline")
+	}
+	// Going offline twice does not count as another session
+	f.command(testUser, channel, "offline")
+
+	res = f.commandOptions(testUser, channel, "stats", map[string]any{"period": "all"})
+	embed := res.Message.Embeds[0]
+	if embed.Title != "Your stats for all time" {
+		t.Errorf("title = %q", embed.Title)
+	}
+	values := make(map[string]string)
+	for _, field := range embed.Fields {
+		values[field.Name] = field.Value
+	}
+	if values["Sessions:"] != "2" || values["Favourite platform:"] != b.Config.Platforms[1].Label || values["Favourite camp:"] != b.Config.Camps[2] {
+		t.Errorf("stats fields = %v", values)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// boardInterval is the least time between two edits of a status board. Every
+// platform channel has a board, so this keeps well within the rate limits.
+const boardInterval = 10 * time.Second
+
+// maxBoardLength leaves room for the line about players not listed within the
+// 4096 characters of an embed description.
+const maxBoardLength = 3900
+
+func statusBoardTitle(p PlatformConfig) string {
+	return p.Emoji + " " + p.Label + " players online"
+}
+
+func statusBoardEmbed(p PlatformConfig, players []Player) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Type:   discordgo.EmbedTypeRich,
+		Color:  colorGrey,
+		Title:  statusBoardTitle(p),
+		Footer: &discordgo.MessageEmbedFooter{Text: "Updates automatically when players go online or offline."},
+	}
+	if len(players) == 0 {
+		embed.Description = "There are no players online at the moment."
+		return embed
+	}
+
+	var description strings.Builder
+	for n, player := range players {
+		// Boards are in the platform channel, where fields hidden from
+		// everyone are left out
+		line := "**" + player.Name + "**"
+		if player.shows(player.Privacy.Bounty, p.Name) {
+			line += " · " + formatBounty(player.Bounty)
+		}
+		if player.Camp != "" && player.shows(player.Privacy.Camp, p.Name) {
+			line += " · " + player.Camp
+		}
+		line += " · online since <t:" + strconv.FormatInt(player.Time.Unix(), 10) + ":R>\n"
+		if player.Footer != "" && player.shows(player.Privacy.Footer, p.Name) {
+			line += "> " + player.Footer + "\n"
+		}
+		if description.Len()+len(line) > maxBoardLength {
+			fmt.Fprintf(&description, "…and %d more", len(players)-n)
+			break
+		}
+		description.WriteString(line)
+	}
+	embed.Description = description.String()
+	return embed
+}
+
+// setupStatusBoards makes sure every platform channel has a pinned status
+// board, reusing the one from a previous run if it still exists.
+func (b *Bot) setupStatusBoards(g *guild) {
+	for _, p := range b.Config.Platforms {
+		channelID, ok := g.Channels.Platforms[p.Name]
+		if !ok {
+			g.log.Warn("No channel for platform, skipping status board", "platform", p.Name)
+			continue
+		}
+		if board := b.setupStatusBoard(g, p, channelID); board != nil {
+			g.BoardMessageIDs[p.Name] = board.ID
+		}
+	}
+
+	// Players may have changed while the bot was down
+	b.refreshBoards(g.Namespace)
+}
+
+// setupStatusBoard finds the status board of the platform or writes a new one.
+func (b *Bot) setupStatusBoard(g *guild, p PlatformConfig, channelID string) *discordgo.Message {
+	// Prefer the stored message, fall back to the pinned messages of the bot
+	if id := g.BoardMessageIDs[p.Name]; id != "" {
+		if board, err := b.Session.ChannelMessage(channelID, id); err == nil {
+			return board
+		}
+	}
+	g.log.Info("Reading pinned messages", "platform", p.Name)
+	pinned, err := b.Session.ChannelMessagesPinned(channelID)
+	if err != nil {
+		b.reportError(g.log, "Error reading pinned messages", err)
+	}
+	for _, m := range pinned {
+		if m.Author != nil && m.Author.ID == b.Session.State.User.ID && len(m.Embeds) > 0 && m.Embeds[0].Title == statusBoardTitle(p) {
+			return m
+		}
+	}
+
+	g.log.Info("Writing status board", "platform", p.Name)
+	board, err := b.Session.ChannelMessageSendEmbed(channelID, statusBoardEmbed(p, nil))
+	if err != nil {
+		b.reportError(g.log, "Error sending message", err)
+		return nil
+	}
+	err = b.Session.ChannelMessagePin(channelID, board.ID)
+	if err != nil {
+		b.reportError(g.log, "Error pinning message", err)
+	}
+	return board
+}
+
+// replaceStatusBoard writes a new status board for one that was deleted.
+func (b *Bot) replaceStatusBoard(g *guild, p PlatformConfig, channelID string) {
+	g.log.Warn("Status board was deleted, writing a new one", "platform", p.Name)
+	delete(g.BoardMessageIDs, p.Name)
+	board := b.setupStatusBoard(g, p, channelID)
+	if board == nil {
+		return
+	}
+	g.BoardMessageIDs[p.Name] = board.ID
+	err := b.Guilds.SaveGuildConfig(context.TODO(), &g.GuildConfig)
+	if err != nil {
+		b.reportError(g.log, "Error saving server config", err)
+	}
+	b.refreshBoards(g.Namespace)
+}
+
+// refreshBoards schedules an update of the status boards of all servers
+// sharing the namespace.
+func (b *Bot) refreshBoards(namespace string) {
+	b.boardsMu.Lock()
+	defer b.boardsMu.Unlock()
+	if b.staleBoards == nil {
+		b.staleBoards = make(map[string]bool)
+	}
+	b.staleBoards[namespace] = true
+}
+
+// runStatusBoards updates stale status boards until ctx is cancelled.
+func (b *Bot) runStatusBoards(ctx context.Context) {
+	ticker := time.NewTicker(boardInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.updateStatusBoards(ctx)
+		}
+	}
+}
+
+// updateStatusBoards edits the status boards of all namespaces that changed
+// since the last update.
+func (b *Bot) updateStatusBoards(ctx context.Context) {
+	b.boardsMu.Lock()
+	stale := b.staleBoards
+	b.staleBoards = nil
+	b.boardsMu.Unlock()
+	if len(stale) == 0 {
+		return
+	}
+
+	for _, g := range b.readyGuilds() {
+		if !stale[g.Namespace] {
+			continue
+		}
+		for _, p := range b.Config.Platforms {
+			channelID, messageID := g.Channels.Platforms[p.Name], g.BoardMessageIDs[p.Name]
+			if channelID == "" || messageID == "" {
+				continue
+			}
+
+			players, err := b.Players.OnlinePlayers(ctx, g.Namespace, p.Name)
+			if err != nil {
+				b.reportError(g.log, "Error reading online players", err)
+				continue
+			}
+			embed := statusBoardEmbed(p, players)
+			if !b.boardChanged(messageID, embed.Description) {
+				continue
+			}
+
+			_, err = b.Session.ChannelMessageEditEmbed(channelID, messageID, embed)
+			if isUnknownMessage(err) {
+				b.boardChanged(messageID, "")
+				b.replaceStatusBoard(g, p, channelID)
+				continue
+			}
+			if err != nil {
+				b.reportError(g.log, "Error editing message", err)
+				// Try again with the next update
+				b.boardChanged(messageID, "")
+				b.refreshBoards(g.Namespace)
+			}
+		}
+	}
+}
+
+// boardChanged remembers the description of a board and reports whether it
+// differs from the previous one.
+func (b *Bot) boardChanged(messageID, description string) bool {
+	b.boardsMu.Lock()
+	defer b.boardsMu.Unlock()
+	if b.boardContent == nil {
+		b.boardContent = make(map[string]string)
+	}
+	if b.boardContent[messageID] == description {
+		return false
+	}
+	b.boardContent[messageID] = description
+	return true
+}
+
+// isUnknownMessage reports whether err is Discord's answer for a message that
+// does not exist (anymore).
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
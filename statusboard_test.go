@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestStatusBoard(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	ctx := context.Background()
+	g := b.guild(testGuildID)
+	platform := b.Config.Platforms[0]
+	channel := f.channelID(platform.Channel)
+
+	board := func() string {
+		t.Helper()
+		messages := f.channelMessages(channel)
+		if len(messages) == 0 || !messages[0].Pinned || messages[0].Embeds[0].Title != statusBoardTitle(platform) {
+			t.Fatalf("no pinned status board in %+v", messages)
+		}
+		return messages[0].Embeds[0].Description
+	}
+	b.updateStatusBoards(ctx)
+	if got := board(); got != "There are no players online at the moment." {
+		t.Errorf("empty board = %q", got)
+	}
+
+	f.submit(testUser, channel, "setup:"+testUser.ID, map[string]string{"rockstar_id": "", "bounty": "12.5", "footer": "Hunting legendaries"})
+	f.command(testUser, channel, "online")
+	f.click(testUser, channel, "camp_selection", b.Config.Camps[0])
+	b.updateStatusBoards(ctx)
+	got := board()
+	for _, want := range []string{"**" + testUser.Username + "**", "$12.50", b.Config.Camps[0], "> Hunting legendaries"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("board %q does not contain %q", got, want)
+		}
+	}
+
+	// Nothing changed, so the board is not edited again
+	b.updateStatusBoards(ctx)
+	if b.boardChanged(g.BoardMessageIDs[platform.Name], got) {
+		t.Error("board content was not remembered")
+	}
+
+	f.command(testUser, channel, "offline")
+	b.updateStatusBoards(ctx)
+	if got := board(); got != "There are no players online at the moment." {
+		t.Errorf("board after going offline = %q", got)
+	}
+
+	// After a restart the pinned board is found again
+	boardID := g.BoardMessageIDs[platform.Name]
+	g.BoardMessageIDs = make(map[string]string)
+	b.setupStatusBoards(g)
+	if g.BoardMessageIDs[platform.Name] != boardID {
+		t.Errorf("board message = %q, want existing %q", g.BoardMessageIDs[platform.Name], boardID)
+	}
+	if messages := f.channelMessages(channel); len(messages) != 1 {
+		t.Errorf("got %d messages in platform channel, want only the board", len(messages))
+	}
+
+	// A deleted board is replaced
+	if err := b.Session.ChannelMessageDelete(channel, boardID); err != nil {
+		t.Fatal(err)
+	}
+	f.command(testUser, channel, "online")
+	b.updateStatusBoards(ctx)
+	if g.BoardMessageIDs[platform.Name] == boardID {
+		t.Error("board message was not replaced")
+	}
+	b.updateStatusBoards(ctx)
+	if got := board(); !strings.Contains(got, "**"+testUser.Username+"**") {
+		t.Errorf("new board %q does not list the online player", got)
+	}
+	config, err := b.Guilds.GuildConfig(ctx, testGuildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.BoardMessageIDs[platform.Name] != g.BoardMessageIDs[platform.Name] {
+		t.Errorf("saved board message = %q, want %q", config.BoardMessageIDs[platform.Name], g.BoardMessageIDs[platform.Name])
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Player struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Namespace string             `bson:"namespace"`
+	Name      string             `bson:"name"`
+	DiscordId string             `bson:"discord_id"`
+	// Profile is the profile on Platform, filled in by the stores.
+	Profile `bson:"-"`
+	// Default is the profile on platforms without their own profile. It holds
+	// the profiles from before they were kept per platform.
+	Default   Profile            `bson:",inline"`
+	Profiles  map[string]Profile `bson:"profiles,omitempty"`
+	Online    bool               `bson:"online"`
+	Platform  string             `bson:"platform"`
+	Time      time.Time          `bson:"time"`
+	Active    time.Time          `bson:"active"`
+	Confirmed time.Time          `bson:"confirmed"`
+	Reminded  bool               `bson:"reminded"`
+	// AutoOnline opts the player in to going online and offline with their
+	// Discord presence, Detected marks sessions started that way.
+	AutoOnline bool `bson:"auto_online"`
+	Detected   bool `bson:"detected"`
+	// Privacy applies to the profiles on all platforms.
+	Privacy Privacy `bson:"privacy"`
+	// Posse is the Discord ID of the leader of the posse the player is in.
+	Posse   string    `bson:"posse,omitempty"`
+	Expires time.Time `bson:"expires"`
+}
+
+// Profile holds the character details of a player on one platform.
+type Profile struct {
+	RockstarId string  `bson:"rockstar_id"`
+	Bounty     float64 `bson:"bounty"`
+	Camp       string  `bson:"camp"`
+	Footer     string  `bson:"footer"`
+	Character  string  `bson:"character,omitempty"`
+	Rank       int     `bson:"rank,omitempty"`
+	// RoleRanks holds the rank in each role by role name.
+	RoleRanks map[string]int `bson:"role_ranks,omitempty"`
+}
+
+// profile returns the profile of the player on platform.
+func (p *Player) profile(platform string) Profile {
+	if profile, ok := p.Profiles[platform]; ok {
+		return profile
+	}
+	return p.Default
+}
+
+// ProfileUpdate holds the profile fields to change. Nil fields are left untouched.
+type ProfileUpdate struct {
+	Name       *string
+	AutoOnline *bool
+	// Privacy sets the visibility of the profile fields with the given keys.
+	Privacy map[string]Visibility
+	// Platform selects the profile the fields below change, empty selects
+	// the default profile. A new platform profile starts as a copy of the
+	// default one.
+	Platform   string
+	RockstarId *string
+	Bounty     *float64
+	Camp       *string
+	Footer     *string
+	Character  *string
+	Rank       *int
+	// RoleRanks replaces all role ranks unless nil.
+	RoleRanks map[string]int
+}
+
+// changesProfile reports whether u changes any profile field.
+func (u ProfileUpdate) changesProfile() bool {
+	return u.RockstarId != nil || u.Bounty != nil || u.Camp != nil || u.Footer != nil ||
+		u.Character != nil || u.Rank != nil || u.RoleRanks != nil
+}
+
+// PlayerKey identifies a player profile. Guilds sharing a namespace share
+// their players.
+type PlayerKey struct {
+	Namespace string
+	DiscordID string
+}
+
+// PlayerStore persists player profiles and their online state. Players are
+// returned with Profile set to their profile on their current platform.
+type PlayerStore interface {
+	// Player returns the profile of the given player or ErrPlayerNotFound.
+	Player(ctx context.Context, key PlayerKey) (*Player, error)
+	// UpsertProfile applies u to the player's profile, creating it if necessary.
+	UpsertProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (created bool, err error)
+	// UpdateProfile applies u to an existing profile and returns the updated player.
+	// Profile updates count as activity of the player.
+	UpdateProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (*Player, error)
+	// SetOnline flags the player as online on platform and returns the updated
+	// player. Detected tells whether the session was started by the player's presence.
+	SetOnline(ctx context.Context, key PlayerKey, platform string, detected bool) (*Player, error)
+	// SetOffline flags the player as offline, removes them from their posse
+	// and returns the player as it was before the update.
+	SetOffline(ctx context.Context, key PlayerKey) (*Player, error)
+	// ExtendSession confirms that an online player is still playing and returns
+	// the updated player or ErrPlayerNotFound if the player is not online.
+	ExtendSession(ctx context.Context, key PlayerKey) (*Player, error)
+	// SetReminded records that the player was asked whether they are still playing.
+	SetReminded(ctx context.Context, key PlayerKey) error
+	// SetPosse puts the player in the posse led by leader, or removes them
+	// from their posse if leader is empty.
+	SetPosse(ctx context.Context, key PlayerKey, leader string) error
+	// JoinPosse puts the player in the posse led by leader unless it already
+	// has max members, which fails with ErrPosseFull, or the player is in a
+	// posse, which fails with ErrAlreadyInPosse.
+	JoinPosse(ctx context.Context, key PlayerKey, leader string, max int) error
+	// DisbandPosse removes all players of namespace from the posse led by leader.
+	DisbandPosse(ctx context.Context, namespace, leader string) error
+	// OnlinePlayers lists the players of namespace online on platform, longest online first.
+	OnlinePlayers(ctx context.Context, namespace, platform string) ([]Player, error)
+	// Players returns the players of namespace with the given Discord IDs,
+	// leaving out those without a profile.
+	Players(ctx context.Context, namespace string, discordIDs []string) ([]Player, error)
+	// Ping checks that the store is reachable.
+	Ping(ctx context.Context) error
+}
+
+// Session is a finished online session of a player.
+type Session struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Namespace string             `bson:"namespace"`
+	DiscordId string             `bson:"discord_id"`
+	Platform  string             `bson:"platform"`
+	Camp      string             `bson:"camp"`
+	Bounty    float64            `bson:"bounty"`
+	Start     time.Time          `bson:"start"`
+	End       time.Time          `bson:"end"`
+}
+
+// SessionStore persists the session history of players.
+type SessionStore interface {
+	// RecordSession adds a finished session.
+	RecordSession(ctx context.Context, s *Session) error
+	// Sessions lists the sessions in namespace that ended after since, of the
+	// player with discordID or of all players if discordID is empty.
+	Sessions(ctx context.Context, namespace, discordID string, since time.Time) ([]Session, error)
+}
+
+// Event is a scheduled group session players can sign up for.
+type Event struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	GuildID   string             `bson:"guild_id"`
+	ChannelID string             `bson:"channel_id"`
+	MessageID string             `bson:"message_id"`
+	Host      string             `bson:"host"`
+	Title     string             `bson:"title"`
+	Activity  string             `bson:"activity"`
+	Platform  string             `bson:"platform"`
+	Start     time.Time          `bson:"start"`
+	// Slots limits the number of attendees, 0 means no limit.
+	Slots int `bson:"slots"`
+	// Attendees and Maybe hold the Discord IDs of players in order of signing up.
+	Attendees []string `bson:"attendees"`
+	Maybe     []string `bson:"maybe"`
+	// Reminded holds how long before the start reminders were sent.
+	Reminded []time.Duration `bson:"reminded"`
+	Expires  time.Time       `bson:"expires"`
+}
+
+// RSVP is the answer of a player to an event.
+type RSVP string
+
+const (
+	RSVPJoin  RSVP = "join"
+	RSVPMaybe RSVP = "maybe"
+	RSVPLeave RSVP = "leave"
+)
+
+// EventStore persists scheduled events and their RSVPs.
+type EventStore interface {
+	// CreateEvent adds a new event with e.ID set by the caller.
+	CreateEvent(ctx context.Context, e *Event) error
+	// Event returns the event with the given ID or ErrEventNotFound.
+	Event(ctx context.Context, id primitive.ObjectID) (*Event, error)
+	// RespondEvent records the answer of a player and returns the updated
+	// event. Joining a full event fails with ErrEventFull.
+	RespondEvent(ctx context.Context, id primitive.ObjectID, discordID string, rsvp RSVP) (*Event, error)
+	// UpcomingEvents lists the events starting after from until to, soonest first.
+	UpcomingEvents(ctx context.Context, from, to time.Time) ([]Event, error)
+	// SetEventReminded records that the reminder lead before the start was sent.
+	SetEventReminded(ctx context.Context, id primitive.ObjectID, lead time.Duration) error
+}
+
+// Group is a looking-for-group post players can join until it is full or
+// closes.
+type Group struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	GuildID   string             `bson:"guild_id"`
+	ChannelID string             `bson:"channel_id"`
+	MessageID string             `bson:"message_id"`
+	Host      string             `bson:"host"`
+	Role      string             `bson:"role"`
+	Platform  string             `bson:"platform"`
+	Size      int                `bson:"size"`
+	// Players holds the host and everyone who joined, in order of joining.
+	Players []string  `bson:"players"`
+	Closes  time.Time `bson:"closes"`
+	Closed  bool      `bson:"closed"`
+	Expires time.Time `bson:"expires"`
+}
+
+// GroupStore persists looking-for-group posts.
+type GroupStore interface {
+	// CreateGroup adds a new group with g.ID set by the caller.
+	CreateGroup(ctx context.Context, g *Group) error
+	// JoinGroup adds the player to an open group and returns the updated
+	// group, which is closed once full. Joining a closed or missing group
+	// fails with ErrGroupClosed, joining twice with ErrAlreadyJoined.
+	JoinGroup(ctx context.Context, id primitive.ObjectID, discordID string) (*Group, error)
+	// CloseGroups closes the open groups closing by now and returns them.
+	CloseGroups(ctx context.Context, now time.Time) ([]Group, error)
+}
+
+// Watchlist holds the players a member wants to be notified about when they
+// go online.
+type Watchlist struct {
+	Namespace string   `bson:"namespace"`
+	DiscordId string   `bson:"discord_id"`
+	Players   []string `bson:"players"`
+	// MutedUntil pauses notifications until the given time.
+	MutedUntil time.Time `bson:"muted_until"`
+}
+
+// WatchStore persists the watchlists of members.
+type WatchStore interface {
+	// Watchlist returns the watchlist of the member, which is empty if they
+	// never watched anyone.
+	Watchlist(ctx context.Context, key PlayerKey) (*Watchlist, error)
+	// Watch adds a player to the watchlist of the member.
+	Watch(ctx context.Context, key PlayerKey, discordID string) error
+	// Unwatch removes a player from the watchlist of the member and reports
+	// whether they were on it.
+	Unwatch(ctx context.Context, key PlayerKey, discordID string) (bool, error)
+	// MuteWatchlist pauses the notifications of the member until the given time.
+	MuteWatchlist(ctx context.Context, key PlayerKey, until time.Time) error
+	// Watchers lists the watchlists in namespace containing the player.
+	Watchers(ctx context.Context, namespace, discordID string) ([]Watchlist, error)
+}
+
+// GuildConfig is the persisted configuration of a single Discord server.
+type GuildConfig struct {
+	GuildID        string        `bson:"guild_id"`
+	Namespace      string        `bson:"namespace"`
+	Channels       GuildChannels `bson:"channels"`
+	RolesMessageID string        `bson:"roles_message_id"`
+	// BoardMessageIDs maps platforms to their status board message
+	BoardMessageIDs map[string]string `bson:"board_message_ids"`
+	// CommandsMessageIDs are the command instruction messages in order
+	CommandsMessageIDs []string `bson:"commands_message_ids"`
+}
+
+// GuildChannels maps the channels the bot works with to their IDs.
+type GuildChannels struct {
+	General   string            `bson:"general"`
+	Commands  string            `bson:"commands"`
+	Roles     string            `bson:"roles"`
+	Bulletin  string            `bson:"bulletin"`
+	Platforms map[string]string `bson:"platforms"`
+}
+
+// GuildStore persists the per guild configuration.
+type GuildStore interface {
+	// GuildConfig returns the configuration of the given guild or ErrGuildNotFound.
+	GuildConfig(ctx context.Context, guildID string) (*GuildConfig, error)
+	// SaveGuildConfig creates or replaces the configuration of c.GuildID.
+	SaveGuildConfig(ctx context.Context, c *GuildConfig) error
+}
+
+var (
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrGuildNotFound  = errors.New("guild not found")
+	ErrEventNotFound  = errors.New("event not found")
+	ErrEventFull      = errors.New("event is full")
+	ErrPosseFull      = errors.New("posse is full")
+	ErrAlreadyInPosse = errors.New("already in a posse")
+	ErrGroupClosed    = errors.New("group is closed")
+	ErrAlreadyJoined  = errors.New("already in group")
+)
+
+// Events expire a week after their start.
+const eventRetention = time.Hour * 24 * 7
+
+// Groups expire a day after they close.
+const groupRetention = time.Hour * 24
+
+// Player documents expire after a year without any update.
+const playerRetention = time.Hour * 24 * 365
+
+func (u ProfileUpdate) apply(p *Player) {
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.AutoOnline != nil {
+		p.AutoOnline = *u.AutoOnline
+	}
+	for key, v := range u.Privacy {
+		if field := p.Privacy.field(key); field != nil {
+			*field = v
+		}
+	}
+	if !u.changesProfile() {
+		return
+	}
+
+	profile := p.profile(u.Platform)
+	if u.RockstarId != nil {
+		profile.RockstarId = *u.RockstarId
+	}
+	if u.Bounty != nil {
+		profile.Bounty = *u.Bounty
+	}
+	if u.Camp != nil {
+		profile.Camp = *u.Camp
+	}
+	if u.Footer != nil {
+		profile.Footer = *u.Footer
+	}
+	if u.Character != nil {
+		profile.Character = *u.Character
+	}
+	if u.Rank != nil {
+		profile.Rank = *u.Rank
+	}
+	if u.RoleRanks != nil {
+		profile.RoleRanks = maps.Clone(u.RoleRanks)
+	}
+	if u.Platform == "" {
+		p.Default = profile
+		return
+	}
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]Profile)
+	}
+	p.Profiles[u.Platform] = profile
+}
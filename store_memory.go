@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryPlayerStore keeps players in memory. It is meant for running the bot
+// without a database and for tests.
+type memoryPlayerStore struct {
+	mu      sync.Mutex
+	players map[PlayerKey]*Player
+}
+
+func newMemoryPlayerStore() *memoryPlayerStore {
+	return &memoryPlayerStore{players: make(map[PlayerKey]*Player)}
+}
+
+func (s *memoryPlayerStore) Player(ctx context.Context, key PlayerKey) (*Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[key]
+	if !ok {
+		return nil, ErrPlayerNotFound
+	}
+	return copyPlayer(p), nil
+}
+
+func (s *memoryPlayerStore) UpsertProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[key]
+	if !ok {
+		p = &Player{ID: primitive.NewObjectID(), Namespace: key.Namespace, DiscordId: key.DiscordID}
+		s.players[key] = p
+	}
+	u.apply(p)
+	p.Active = time.Now()
+	p.Reminded = false
+	p.Expires = time.Now().Add(playerRetention)
+	return !ok, nil
+}
+
+func (s *memoryPlayerStore) UpdateProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (*Player, error) {
+	return s.update(key, false, func(p *Player) {
+		u.apply(p)
+		p.Active = time.Now()
+		p.Reminded = false
+	})
+}
+
+func (s *memoryPlayerStore) SetOnline(ctx context.Context, key PlayerKey, platform string, detected bool) (*Player, error) {
+	return s.update(key, false, func(p *Player) {
+		p.Online = true
+		p.Platform = platform
+		p.Time = time.Now()
+		p.Active = p.Time
+		p.Confirmed = p.Time
+		p.Reminded = false
+		p.Detected = detected
+	})
+}
+
+func (s *memoryPlayerStore) SetOffline(ctx context.Context, key PlayerKey) (*Player, error) {
+	return s.update(key, true, func(p *Player) {
+		p.Online = false
+		p.Detected = false
+		p.Posse = ""
+		p.Time = time.Now()
+	})
+}
+
+func (s *memoryPlayerStore) ExtendSession(ctx context.Context, key PlayerKey) (*Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[key]
+	if !ok || !p.Online {
+		return nil, ErrPlayerNotFound
+	}
+	p.Active = time.Now()
+	p.Confirmed = p.Active
+	p.Reminded = false
+	p.Expires = time.Now().Add(playerRetention)
+	return copyPlayer(p), nil
+}
+
+func (s *memoryPlayerStore) SetReminded(ctx context.Context, key PlayerKey) error {
+	_, err := s.update(key, false, func(p *Player) {
+		p.Reminded = true
+	})
+	return err
+}
+
+func (s *memoryPlayerStore) SetPosse(ctx context.Context, key PlayerKey, leader string) error {
+	_, err := s.update(key, false, func(p *Player) {
+		p.Posse = leader
+	})
+	return err
+}
+
+func (s *memoryPlayerStore) JoinPosse(ctx context.Context, key PlayerKey, leader string, max int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[key]
+	if !ok {
+		return ErrPlayerNotFound
+	}
+	if p.Posse != "" {
+		return ErrAlreadyInPosse
+	}
+	members := 0
+	for _, other := range s.players {
+		if other.Namespace == key.Namespace && other.Posse == leader {
+			members++
+		}
+	}
+	if members >= max {
+		return ErrPosseFull
+	}
+	p.Posse = leader
+	return nil
+}
+
+func (s *memoryPlayerStore) DisbandPosse(ctx context.Context, namespace, leader string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.players {
+		if p.Namespace == namespace && p.Posse == leader {
+			p.Posse = ""
+		}
+	}
+	return nil
+}
+
+func (s *memoryPlayerStore) OnlinePlayers(ctx context.Context, namespace, platform string) ([]Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Player
+	for _, p := range s.players {
+		if p.Namespace == namespace && p.Online && p.Platform == platform {
+			results = append(results, *copyPlayer(p))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Time.Before(results[j].Time)
+	})
+	return results, nil
+}
+
+func (s *memoryPlayerStore) Players(ctx context.Context, namespace string, discordIDs []string) ([]Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Player
+	for _, id := range discordIDs {
+		if p, ok := s.players[PlayerKey{Namespace: namespace, DiscordID: id}]; ok {
+			results = append(results, *copyPlayer(p))
+		}
+	}
+	return results, nil
+}
+
+func (s *memoryPlayerStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// update applies fn to the stored player and returns a copy of it, either as
+// it was before the update or after it.
+func (s *memoryPlayerStore) update(key PlayerKey, before bool, fn func(p *Player)) (*Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.players[key]
+	if !ok {
+		return nil, ErrPlayerNotFound
+	}
+	previous := copyPlayer(p)
+	fn(p)
+	p.Expires = time.Now().Add(playerRetention)
+
+	if before {
+		return previous, nil
+	}
+	return copyPlayer(p), nil
+}
+
+// copyPlayer returns a copy of p with the profile of its current platform.
+func copyPlayer(p *Player) *Player {
+	c := *p
+	c.Default = copyProfile(p.Default)
+	if p.Profiles != nil {
+		c.Profiles = make(map[string]Profile, len(p.Profiles))
+		for platform, profile := range p.Profiles {
+			c.Profiles[platform] = copyProfile(profile)
+		}
+	}
+	c.Profile = c.profile(c.Platform)
+	return &c
+}
+
+func copyProfile(p Profile) Profile {
+	p.RoleRanks = maps.Clone(p.RoleRanks)
+	return p
+}
+
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions []Session
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{}
+}
+
+func (s *memorySessionStore) RecordSession(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded := *session
+	recorded.ID = primitive.NewObjectID()
+	s.sessions = append(s.sessions, recorded)
+	return nil
+}
+
+func (s *memorySessionStore) Sessions(ctx context.Context, namespace, discordID string, since time.Time) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Session
+	for _, session := range s.sessions {
+		if session.Namespace == namespace && (discordID == "" || session.DiscordId == discordID) && session.End.After(since) {
+			results = append(results, session)
+		}
+	}
+	return results, nil
+}
+
+type memoryGuildStore struct {
+	mu     sync.Mutex
+	guilds map[string]GuildConfig
+}
+
+func newMemoryGuildStore() *memoryGuildStore {
+	return &memoryGuildStore{guilds: make(map[string]GuildConfig)}
+}
+
+func (s *memoryGuildStore) GuildConfig(ctx context.Context, guildID string) (*GuildConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.guilds[guildID]
+	if !ok {
+		return nil, ErrGuildNotFound
+	}
+	c.Channels.Platforms = copyMap(c.Channels.Platforms)
+	c.BoardMessageIDs = copyMap(c.BoardMessageIDs)
+	c.CommandsMessageIDs = slices.Clone(c.CommandsMessageIDs)
+	return &c, nil
+}
+
+func (s *memoryGuildStore) SaveGuildConfig(ctx context.Context, c *GuildConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *c
+	saved.Channels.Platforms = copyMap(c.Channels.Platforms)
+	saved.BoardMessageIDs = copyMap(c.BoardMessageIDs)
+	saved.CommandsMessageIDs = slices.Clone(c.CommandsMessageIDs)
+	s.guilds[c.GuildID] = saved
+	return nil
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+type memoryEventStore struct {
+	mu     sync.Mutex
+	events map[primitive.ObjectID]*Event
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{events: make(map[primitive.ObjectID]*Event)}
+}
+
+func (s *memoryEventStore) CreateEvent(ctx context.Context, e *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := copyEvent(e)
+	created.Expires = e.Start.Add(eventRetention)
+	s.events[e.ID] = created
+	return nil
+}
+
+func (s *memoryEventStore) Event(ctx context.Context, id primitive.ObjectID) (*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	return copyEvent(e), nil
+}
+
+func (s *memoryEventStore) RespondEvent(ctx context.Context, id primitive.ObjectID, discordID string, rsvp RSVP) (*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+	joined := contains(e.Attendees, discordID)
+	if rsvp == RSVPJoin && !joined && e.Slots > 0 && len(e.Attendees) >= e.Slots {
+		return nil, ErrEventFull
+	}
+
+	e.Attendees = remove(e.Attendees, discordID)
+	e.Maybe = remove(e.Maybe, discordID)
+	switch rsvp {
+	case RSVPJoin:
+		e.Attendees = append(e.Attendees, discordID)
+	case RSVPMaybe:
+		e.Maybe = append(e.Maybe, discordID)
+	}
+	return copyEvent(e), nil
+}
+
+func (s *memoryEventStore) UpcomingEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Event
+	for _, e := range s.events {
+		if e.Start.After(from) && !e.Start.After(to) {
+			results = append(results, *copyEvent(e))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Start.Before(results[j].Start)
+	})
+	return results, nil
+}
+
+func (s *memoryEventStore) SetEventReminded(ctx context.Context, id primitive.ObjectID, lead time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return ErrEventNotFound
+	}
+	for _, d := range e.Reminded {
+		if d == lead {
+			return nil
+		}
+	}
+	e.Reminded = append(e.Reminded, lead)
+	return nil
+}
+
+func copyEvent(e *Event) *Event {
+	c := *e
+	c.Attendees = append([]string{}, e.Attendees...)
+	c.Maybe = append([]string{}, e.Maybe...)
+	c.Reminded = append([]time.Duration(nil), e.Reminded...)
+	return &c
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns list without s, keeping the order.
+func remove(list []string, s string) []string {
+	var result []string
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+type memoryGroupStore struct {
+	mu     sync.Mutex
+	groups map[primitive.ObjectID]*Group
+}
+
+func newMemoryGroupStore() *memoryGroupStore {
+	return &memoryGroupStore{groups: make(map[primitive.ObjectID]*Group)}
+}
+
+func (s *memoryGroupStore) CreateGroup(ctx context.Context, g *Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := copyGroup(g)
+	created.Expires = g.Closes.Add(groupRetention)
+	s.groups[g.ID] = created
+	return nil
+}
+
+func (s *memoryGroupStore) JoinGroup(ctx context.Context, id primitive.ObjectID, discordID string) (*Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[id]
+	if !ok || g.Closed {
+		return nil, ErrGroupClosed
+	}
+	if contains(g.Players, discordID) {
+		return nil, ErrAlreadyJoined
+	}
+	g.Players = append(g.Players, discordID)
+	g.Closed = len(g.Players) >= g.Size
+	return copyGroup(g), nil
+}
+
+func (s *memoryGroupStore) CloseGroups(ctx context.Context, now time.Time) ([]Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var closed []Group
+	for _, g := range s.groups {
+		if !g.Closed && !g.Closes.After(now) {
+			g.Closed = true
+			closed = append(closed, *copyGroup(g))
+		}
+	}
+	return closed, nil
+}
+
+func copyGroup(g *Group) *Group {
+	c := *g
+	c.Players = slices.Clone(g.Players)
+	return &c
+}
+
+type memoryWatchStore struct {
+	mu         sync.Mutex
+	watchlists map[PlayerKey]*Watchlist
+}
+
+func newMemoryWatchStore() *memoryWatchStore {
+	return &memoryWatchStore{watchlists: make(map[PlayerKey]*Watchlist)}
+}
+
+func (s *memoryWatchStore) Watchlist(ctx context.Context, key PlayerKey) (*Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := *s.watchlist(key)
+	w.Players = append([]string(nil), w.Players...)
+	return &w, nil
+}
+
+func (s *memoryWatchStore) Watch(ctx context.Context, key PlayerKey, discordID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.watchlist(key)
+	if !contains(w.Players, discordID) {
+		w.Players = append(w.Players, discordID)
+	}
+	s.watchlists[key] = w
+	return nil
+}
+
+func (s *memoryWatchStore) Unwatch(ctx context.Context, key PlayerKey, discordID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watchlists[key]
+	if !ok || !contains(w.Players, discordID) {
+		return false, nil
+	}
+	w.Players = remove(w.Players, discordID)
+	return true, nil
+}
+
+func (s *memoryWatchStore) MuteWatchlist(ctx context.Context, key PlayerKey, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := s.watchlist(key)
+	w.MutedUntil = until
+	s.watchlists[key] = w
+	return nil
+}
+
+func (s *memoryWatchStore) Watchers(ctx context.Context, namespace, discordID string) ([]Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Watchlist
+	for _, w := range s.watchlists {
+		if w.Namespace == namespace && contains(w.Players, discordID) {
+			result := *w
+			result.Players = append([]string(nil), w.Players...)
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// watchlist returns the stored watchlist of the member or a new empty one.
+func (s *memoryWatchStore) watchlist(key PlayerKey) *Watchlist {
+	if w, ok := s.watchlists[key]; ok {
+		return w
+	}
+	return &Watchlist{Namespace: key.Namespace, DiscordId: key.DiscordID}
+}
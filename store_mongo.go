@@ -0,0 +1,643 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoPlayerStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoPlayerStore(ctx context.Context, coll *mongo.Collection) (*mongoPlayerStore, error) {
+	// Create TTL index
+	_, err := coll.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(1),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit posses to one member per place, players who left have no posse
+	_, err = coll.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "namespace", Value: 1}, {Key: "posse", Value: 1}, {Key: "posse_slot", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{
+				{Key: "posse", Value: bson.M{"$gt": ""}},
+				{Key: "posse_slot", Value: bson.M{"$exists": true}},
+			}),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoPlayerStore{coll: coll}, nil
+}
+
+func (s *mongoPlayerStore) Player(ctx context.Context, key PlayerKey) (*Player, error) {
+	var result Player
+	err := s.coll.FindOne(ctx, playerFilter(key)).Decode(&result)
+	if err != nil {
+		return nil, playerErr(err)
+	}
+	result.Profile = result.profile(result.Platform)
+	return &result, nil
+}
+
+func (s *mongoPlayerStore) UpsertProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx, playerFilter(key), profileUpdate(u), options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+func (s *mongoPlayerStore) UpdateProfile(ctx context.Context, key PlayerKey, u ProfileUpdate) (*Player, error) {
+	var result Player
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err := s.coll.FindOneAndUpdate(ctx, playerFilter(key), profileUpdate(u), opts).Decode(&result)
+	if err != nil {
+		return nil, playerErr(err)
+	}
+	result.Profile = result.profile(result.Platform)
+	return &result, nil
+}
+
+func (s *mongoPlayerStore) SetOnline(ctx context.Context, key PlayerKey, platform string, detected bool) (*Player, error) {
+	now := time.Now()
+	return s.findOneAndSet(ctx, key, bson.D{
+		{Key: "online", Value: true},
+		{Key: "platform", Value: platform},
+		{Key: "time", Value: now},
+		{Key: "active", Value: now},
+		{Key: "confirmed", Value: now},
+		{Key: "reminded", Value: false},
+		{Key: "detected", Value: detected},
+		{Key: "expires", Value: now.Add(playerRetention)},
+	}, options.After)
+}
+
+func (s *mongoPlayerStore) SetOffline(ctx context.Context, key PlayerKey) (*Player, error) {
+	return s.findOneAndSet(ctx, key, bson.D{
+		{Key: "online", Value: false},
+		{Key: "detected", Value: false},
+		{Key: "posse", Value: ""},
+		{Key: "time", Value: time.Now()},
+		{Key: "expires", Value: time.Now().Add(playerRetention)},
+	}, options.Before)
+}
+
+func (s *mongoPlayerStore) ExtendSession(ctx context.Context, key PlayerKey) (*Player, error) {
+	var result Player
+	now := time.Now()
+	filter := append(playerFilter(key), bson.E{Key: "online", Value: true})
+	update := bson.M{"$set": bson.D{
+		{Key: "active", Value: now},
+		{Key: "confirmed", Value: now},
+		{Key: "reminded", Value: false},
+		{Key: "expires", Value: now.Add(playerRetention)},
+	}}
+
+	err := s.coll.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&result)
+	if err != nil {
+		return nil, playerErr(err)
+	}
+	result.Profile = result.profile(result.Platform)
+	return &result, nil
+}
+
+func (s *mongoPlayerStore) SetReminded(ctx context.Context, key PlayerKey) error {
+	_, err := s.coll.UpdateOne(ctx, playerFilter(key), bson.M{"$set": bson.D{{Key: "reminded", Value: true}}})
+	return err
+}
+
+func (s *mongoPlayerStore) SetPosse(ctx context.Context, key PlayerKey, leader string) error {
+	// Leaders have no place, which may be left from an earlier posse
+	update := bson.M{"$set": bson.D{{Key: "posse", Value: leader}}, "$unset": bson.D{{Key: "posse_slot", Value: ""}}}
+	res, err := s.coll.UpdateOne(ctx, playerFilter(key), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrPlayerNotFound
+	}
+	return nil
+}
+
+func (s *mongoPlayerStore) JoinPosse(ctx context.Context, key PlayerKey, leader string, max int) error {
+	// Members take a numbered place next to the leader, which the unique
+	// index gives to one player only
+	notInPosse := append(playerFilter(key), bson.E{Key: "posse", Value: bson.M{"$in": bson.A{nil, ""}}})
+	for slot := 1; slot < max; slot++ {
+		res, err := s.coll.UpdateOne(ctx, notInPosse, bson.M{"$set": bson.D{{Key: "posse", Value: leader}, {Key: "posse_slot", Value: slot}}})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			n, err := s.coll.CountDocuments(ctx, playerFilter(key))
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return ErrPlayerNotFound
+			}
+			return ErrAlreadyInPosse
+		}
+		return nil
+	}
+	return ErrPosseFull
+}
+
+func (s *mongoPlayerStore) DisbandPosse(ctx context.Context, namespace, leader string) error {
+	filter := bson.D{{Key: "namespace", Value: namespace}, {Key: "posse", Value: leader}}
+	_, err := s.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.D{{Key: "posse", Value: ""}}})
+	return err
+}
+
+func (s *mongoPlayerStore) OnlinePlayers(ctx context.Context, namespace, platform string) ([]Player, error) {
+	var results []Player
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: 1}})
+
+	cursor, err := s.coll.Find(ctx, bson.D{{Key: "namespace", Value: namespace}, {Key: "platform", Value: platform}, {Key: "online", Value: true}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	for n := range results {
+		results[n].Profile = results[n].profile(results[n].Platform)
+	}
+	return results, nil
+}
+
+func (s *mongoPlayerStore) Players(ctx context.Context, namespace string, discordIDs []string) ([]Player, error) {
+	var results []Player
+	cursor, err := s.coll.Find(ctx, bson.D{{Key: "namespace", Value: namespace}, {Key: "discord_id", Value: bson.M{"$in": discordIDs}}})
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	for n := range results {
+		results[n].Profile = results[n].profile(results[n].Platform)
+	}
+	return results, nil
+}
+
+func (s *mongoPlayerStore) Ping(ctx context.Context) error {
+	return s.coll.Database().Client().Ping(ctx, nil)
+}
+
+func (s *mongoPlayerStore) findOneAndSet(ctx context.Context, key PlayerKey, set bson.D, doc options.ReturnDocument) (*Player, error) {
+	var result Player
+	opts := options.FindOneAndUpdate().SetReturnDocument(doc)
+
+	err := s.coll.FindOneAndUpdate(ctx, playerFilter(key), bson.M{"$set": set}, opts).Decode(&result)
+	if err != nil {
+		return nil, playerErr(err)
+	}
+	result.Profile = result.profile(result.Platform)
+	return &result, nil
+}
+
+// adoptLegacyPlayers moves players created before guild namespaces existed
+// into namespace.
+func (s *mongoPlayerStore) adoptLegacyPlayers(ctx context.Context, namespace string) error {
+	filter := bson.D{{Key: "namespace", Value: bson.D{{Key: "$exists", Value: false}}}}
+	_, err := s.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.D{{Key: "namespace", Value: namespace}}})
+	return err
+}
+
+func playerFilter(key PlayerKey) bson.D {
+	return bson.D{{Key: "namespace", Value: key.Namespace}, {Key: "discord_id", Value: key.DiscordID}}
+}
+
+// profileUpdate returns the update applying u. Changes to a platform profile
+// are made with a pipeline, which starts new profiles from the default one.
+func profileUpdate(u ProfileUpdate) any {
+	var fields bson.D
+	if u.Name != nil {
+		fields = append(fields, bson.E{Key: "name", Value: *u.Name})
+	}
+	if u.AutoOnline != nil {
+		fields = append(fields, bson.E{Key: "auto_online", Value: *u.AutoOnline})
+	}
+	for key, v := range u.Privacy {
+		fields = append(fields, bson.E{Key: "privacy." + key, Value: v})
+	}
+	fields = append(fields,
+		bson.E{Key: "active", Value: time.Now()},
+		bson.E{Key: "reminded", Value: false},
+		bson.E{Key: "expires", Value: time.Now().Add(playerRetention)},
+	)
+
+	var profile bson.D
+	if u.RockstarId != nil {
+		profile = append(profile, bson.E{Key: "rockstar_id", Value: *u.RockstarId})
+	}
+	if u.Bounty != nil {
+		profile = append(profile, bson.E{Key: "bounty", Value: *u.Bounty})
+	}
+	if u.Camp != nil {
+		profile = append(profile, bson.E{Key: "camp", Value: *u.Camp})
+	}
+	if u.Footer != nil {
+		profile = append(profile, bson.E{Key: "footer", Value: *u.Footer})
+	}
+	if u.Character != nil {
+		profile = append(profile, bson.E{Key: "character", Value: *u.Character})
+	}
+	if u.Rank != nil {
+		profile = append(profile, bson.E{Key: "rank", Value: *u.Rank})
+	}
+	if u.RoleRanks != nil {
+		profile = append(profile, bson.E{Key: "role_ranks", Value: u.RoleRanks})
+	}
+	if u.Platform == "" || len(profile) == 0 {
+		return bson.M{"$set": append(fields, profile...)}
+	}
+
+	// Values in a pipeline are expressions, so user input starting with $
+	// must not be taken for a field path
+	for n := range fields {
+		fields[n].Value = bson.D{{Key: "$literal", Value: fields[n].Value}}
+	}
+	for n := range profile {
+		profile[n].Value = bson.D{{Key: "$literal", Value: profile[n].Value}}
+	}
+	path := "profiles." + u.Platform
+	fields = append(fields, bson.E{Key: path, Value: bson.D{{Key: "$mergeObjects", Value: bson.A{
+		bson.D{
+			{Key: "rockstar_id", Value: "$rockstar_id"}, {Key: "bounty", Value: "$bounty"}, {Key: "camp", Value: "$camp"}, {Key: "footer", Value: "$footer"},
+			{Key: "character", Value: "$character"}, {Key: "rank", Value: "$rank"}, {Key: "role_ranks", Value: "$role_ranks"},
+		},
+		"$" + path,
+		profile,
+	}}}})
+	return mongo.Pipeline{{{Key: "$set", Value: fields}}}
+}
+
+func playerErr(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrPlayerNotFound
+	}
+	return err
+}
+
+type mongoSessionStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoSessionStore(ctx context.Context, coll *mongo.Collection) (*mongoSessionStore, error) {
+	_, err := coll.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bson.D{{Key: "namespace", Value: 1}, {Key: "discord_id", Value: 1}, {Key: "end", Value: 1}},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoSessionStore{coll: coll}, nil
+}
+
+func (s *mongoSessionStore) RecordSession(ctx context.Context, session *Session) error {
+	_, err := s.coll.InsertOne(ctx, session)
+	return err
+}
+
+func (s *mongoSessionStore) Sessions(ctx context.Context, namespace, discordID string, since time.Time) ([]Session, error) {
+	filter := bson.D{{Key: "namespace", Value: namespace}}
+	if discordID != "" {
+		filter = append(filter, bson.E{Key: "discord_id", Value: discordID})
+	}
+	filter = append(filter, bson.E{Key: "end", Value: bson.D{{Key: "$gt", Value: since}}})
+
+	cursor, err := s.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var results []Session
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+type mongoGuildStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoGuildStore(ctx context.Context, coll *mongo.Collection) (*mongoGuildStore, error) {
+	_, err := coll.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "guild_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoGuildStore{coll: coll}, nil
+}
+
+func (s *mongoGuildStore) GuildConfig(ctx context.Context, guildID string) (*GuildConfig, error) {
+	var result GuildConfig
+	err := s.coll.FindOne(ctx, bson.D{{Key: "guild_id", Value: guildID}}).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrGuildNotFound
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *mongoGuildStore) SaveGuildConfig(ctx context.Context, c *GuildConfig) error {
+	filter := bson.D{{Key: "guild_id", Value: c.GuildID}}
+	_, err := s.coll.ReplaceOne(ctx, filter, c, options.Replace().SetUpsert(true))
+	return err
+}
+
+type mongoEventStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoEventStore(ctx context.Context, coll *mongo.Collection) (*mongoEventStore, error) {
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(1),
+		},
+		{
+			Keys: bson.D{{Key: "start", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoEventStore{coll: coll}, nil
+}
+
+func (s *mongoEventStore) CreateEvent(ctx context.Context, e *Event) error {
+	created := *e
+	// The slot check relies on the lists being arrays
+	created.Attendees = append([]string{}, e.Attendees...)
+	created.Maybe = append([]string{}, e.Maybe...)
+	created.Expires = e.Start.Add(eventRetention)
+	_, err := s.coll.InsertOne(ctx, &created)
+	return err
+}
+
+func (s *mongoEventStore) Event(ctx context.Context, id primitive.ObjectID) (*Event, error) {
+	var result Event
+	err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&result)
+	if err != nil {
+		return nil, eventErr(err)
+	}
+	return &result, nil
+}
+
+func (s *mongoEventStore) RespondEvent(ctx context.Context, id primitive.ObjectID, discordID string, rsvp RSVP) (*Event, error) {
+	filter := bson.D{{Key: "_id", Value: id}}
+	var update bson.D
+	switch rsvp {
+	case RSVPJoin:
+		// Only join if already attending or there is a free slot
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "slots", Value: 0}},
+			bson.D{{Key: "attendees", Value: discordID}},
+			bson.D{{Key: "$expr", Value: bson.D{{Key: "$lt", Value: bson.A{bson.D{{Key: "$size", Value: "$attendees"}}, "$slots"}}}}},
+		}})
+		update = bson.D{
+			{Key: "$pull", Value: bson.D{{Key: "maybe", Value: discordID}}},
+			{Key: "$addToSet", Value: bson.D{{Key: "attendees", Value: discordID}}},
+		}
+	case RSVPMaybe:
+		update = bson.D{
+			{Key: "$pull", Value: bson.D{{Key: "attendees", Value: discordID}}},
+			{Key: "$addToSet", Value: bson.D{{Key: "maybe", Value: discordID}}},
+		}
+	default:
+		update = bson.D{
+			{Key: "$pull", Value: bson.D{{Key: "attendees", Value: discordID}, {Key: "maybe", Value: discordID}}},
+		}
+	}
+
+	var result Event
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments && rsvp == RSVPJoin {
+		// Tell a full event from a missing one
+		if _, err := s.Event(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrEventFull
+	}
+	if err != nil {
+		return nil, eventErr(err)
+	}
+	return &result, nil
+}
+
+func (s *mongoEventStore) UpcomingEvents(ctx context.Context, from, to time.Time) ([]Event, error) {
+	filter := bson.D{{Key: "start", Value: bson.D{{Key: "$gt", Value: from}, {Key: "$lte", Value: to}}}}
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var results []Event
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *mongoEventStore) SetEventReminded(ctx context.Context, id primitive.ObjectID, lead time.Duration) error {
+	_, err := s.coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.M{"$addToSet": bson.D{{Key: "reminded", Value: lead}}})
+	return err
+}
+
+func eventErr(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrEventNotFound
+	}
+	return err
+}
+
+type mongoGroupStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoGroupStore(ctx context.Context, coll *mongo.Collection) (*mongoGroupStore, error) {
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(1),
+		},
+		{
+			Keys: bson.D{{Key: "closed", Value: 1}, {Key: "closes", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoGroupStore{coll: coll}, nil
+}
+
+func (s *mongoGroupStore) CreateGroup(ctx context.Context, g *Group) error {
+	created := *g
+	// Joining relies on the list being an array
+	created.Players = append([]string{}, g.Players...)
+	created.Expires = g.Closes.Add(groupRetention)
+	_, err := s.coll.InsertOne(ctx, &created)
+	return err
+}
+
+func (s *mongoGroupStore) JoinGroup(ctx context.Context, id primitive.ObjectID, discordID string) (*Group, error) {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "closed", Value: false},
+		{Key: "players", Value: bson.D{{Key: "$ne", Value: discordID}}},
+	}
+	// The second stage sees the players including the one joining
+	joined := bson.D{{Key: "$concatArrays", Value: bson.A{"$players", bson.A{bson.D{{Key: "$literal", Value: discordID}}}}}}
+	full := bson.D{{Key: "$gte", Value: bson.A{bson.D{{Key: "$size", Value: "$players"}}, "$size"}}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{{Key: "players", Value: joined}}}},
+		{{Key: "$set", Value: bson.D{{Key: "closed", Value: full}}}},
+	}
+
+	var result Group
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := s.coll.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		// Tell a player already in the group from a closed or missing group
+		var g Group
+		err := s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&g)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		if err == nil && !g.Closed && contains(g.Players, discordID) {
+			return nil, ErrAlreadyJoined
+		}
+		return nil, ErrGroupClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *mongoGroupStore) CloseGroups(ctx context.Context, now time.Time) ([]Group, error) {
+	filter := bson.D{{Key: "closed", Value: false}, {Key: "closes", Value: bson.D{{Key: "$lte", Value: now}}}}
+	cursor, err := s.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var due []Group
+	if err = cursor.All(ctx, &due); err != nil {
+		return nil, err
+	}
+
+	// Another instance may close the same groups, only return those closed here
+	var closed []Group
+	for _, g := range due {
+		res, err := s.coll.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: g.ID}, {Key: "closed", Value: false}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "closed", Value: true}}}},
+		)
+		if err != nil {
+			return closed, err
+		}
+		if res.ModifiedCount > 0 {
+			g.Closed = true
+			closed = append(closed, g)
+		}
+	}
+	return closed, nil
+}
+
+type mongoWatchStore struct {
+	coll *mongo.Collection
+}
+
+func newMongoWatchStore(ctx context.Context, coll *mongo.Collection) (*mongoWatchStore, error) {
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "namespace", Value: 1}, {Key: "discord_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "namespace", Value: 1}, {Key: "players", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoWatchStore{coll: coll}, nil
+}
+
+func (s *mongoWatchStore) Watchlist(ctx context.Context, key PlayerKey) (*Watchlist, error) {
+	result := Watchlist{Namespace: key.Namespace, DiscordId: key.DiscordID}
+	err := s.coll.FindOne(ctx, playerFilter(key)).Decode(&result)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *mongoWatchStore) Watch(ctx context.Context, key PlayerKey, discordID string) error {
+	update := bson.M{"$addToSet": bson.D{{Key: "players", Value: discordID}}}
+	_, err := s.coll.UpdateOne(ctx, playerFilter(key), update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *mongoWatchStore) Unwatch(ctx context.Context, key PlayerKey, discordID string) (bool, error) {
+	update := bson.M{"$pull": bson.D{{Key: "players", Value: discordID}}}
+	res, err := s.coll.UpdateOne(ctx, playerFilter(key), update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (s *mongoWatchStore) MuteWatchlist(ctx context.Context, key PlayerKey, until time.Time) error {
+	update := bson.M{"$set": bson.D{{Key: "muted_until", Value: until}}}
+	_, err := s.coll.UpdateOne(ctx, playerFilter(key), update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *mongoWatchStore) Watchers(ctx context.Context, namespace, discordID string) ([]Watchlist, error) {
+	cursor, err := s.coll.Find(ctx, bson.D{{Key: "namespace", Value: namespace}, {Key: "players", Value: discordID}})
+	if err != nil {
+		return nil, err
+	}
+	var results []Watchlist
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
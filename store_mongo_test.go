@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase returns an empty database on the server at MONGODB_TEST_URI,
+// skipping the test if it is not set. The database is dropped afterwards.
+func testDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("rdo_test_" + strconv.FormatInt(time.Now().UnixNano(), 36))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestMongoProfileUpdate(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+	store, err := newMongoPlayerStore(ctx, db.Collection("players"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := PlayerKey{Namespace: "100", DiscordID: "300"}
+	name, rockstarID, bounty, footer, camp := "arthur", "123456789", 12.5, "Hunting legendaries", "Trapper"
+
+	if _, err := store.UpsertProfile(ctx, key, ProfileUpdate{Name: &name, RockstarId: &rockstarID, Bounty: &bounty, Footer: &footer}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new platform profile starts from the default one, values are taken
+	// literally even if they look like field paths
+	fieldPath := "$footer"
+	p, err := store.UpdateProfile(ctx, key, ProfileUpdate{Platform: "PS4", Footer: &fieldPath, RoleRanks: map[string]int{"Trader": 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Profiles["PS4"]; got.RockstarId != rockstarID || got.Bounty != bounty || got.Footer != "$footer" || got.RoleRanks["Trader"] != 20 {
+		t.Errorf("PS4 profile = %+v", got)
+	}
+
+	// Existing platform profiles keep the fields not changed
+	p, err = store.UpdateProfile(ctx, key, ProfileUpdate{Platform: "PS4", Camp: &camp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Profiles["PS4"]; got.Camp != camp || got.Footer != "$footer" || got.RoleRanks["Trader"] != 20 {
+		t.Errorf("PS4 profile after changing camp = %+v", got)
+	}
+	if p.Default.Footer != footer || p.Default.Camp != "" || p.Default.RoleRanks != nil {
+		t.Errorf("default profile = %+v, want unchanged", p.Default)
+	}
+	if p.Name != name {
+		t.Errorf("name = %q, want %q", p.Name, name)
+	}
+}
+
+func TestMongoGroups(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+	store, err := newMongoGroupStore(ctx, db.Collection("groups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	group := &Group{ID: primitive.NewObjectID(), Host: "300", Size: 2, Players: []string{"300"}, Closes: now.Add(time.Hour)}
+	if err := store.CreateGroup(ctx, group); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.JoinGroup(ctx, group.ID, "300"); err != ErrAlreadyJoined {
+		t.Errorf("host joining = %v, want ErrAlreadyJoined", err)
+	}
+	g, err := store.JoinGroup(ctx, group.ID, "301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Closed || len(g.Players) != 2 || g.Players[1] != "301" {
+		t.Errorf("full group = %+v, want closed with 2 players", g)
+	}
+	if _, err := store.JoinGroup(ctx, group.ID, "302"); err != ErrGroupClosed {
+		t.Errorf("joining a full group = %v, want ErrGroupClosed", err)
+	}
+	if _, err := store.JoinGroup(ctx, primitive.NewObjectID(), "302"); err != ErrGroupClosed {
+		t.Errorf("joining a missing group = %v, want ErrGroupClosed", err)
+	}
+
+	open := &Group{ID: primitive.NewObjectID(), Host: "300", Size: 4, Players: []string{"300"}, Closes: now.Add(time.Hour)}
+	if err := store.CreateGroup(ctx, open); err != nil {
+		t.Fatal(err)
+	}
+	closed, err := store.CloseGroups(ctx, now)
+	if err != nil || len(closed) != 0 {
+		t.Errorf("closing before the time = %v, %v", closed, err)
+	}
+	closed, err = store.CloseGroups(ctx, now.Add(time.Hour))
+	if err != nil || len(closed) != 1 || closed[0].ID != open.ID || !closed[0].Closed {
+		t.Errorf("closing after the time = %+v, %v, want only the open group", closed, err)
+	}
+}
+
+func TestMongoJoinPosseLimit(t *testing.T) {
+	db := testDatabase(t)
+	store, err := newMongoPlayerStore(context.Background(), db.Collection("players"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testJoinPosseLimit(t, store)
+}
+
+func TestMongoPlayers(t *testing.T) {
+	db := testDatabase(t)
+	store, err := newMongoPlayerStore(context.Background(), db.Collection("players"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testPlayers(t, store)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxWatched limits the number of players on a watchlist.
+const maxWatched = 25
+
+// muteDurations are the choices for pausing watchlist notifications.
+var muteDurations = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"1 hour", time.Hour},
+	{"4 hours", 4 * time.Hour},
+	{"12 hours", 12 * time.Hour},
+	{"1 day", 24 * time.Hour},
+	{"1 week", 7 * 24 * time.Hour},
+	{"Unmute", 0},
+}
+
+func muteChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, d := range muteDurations {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: d.Label, Value: d.Duration.String()})
+	}
+	return choices
+}
+
+func (b *Bot) watchPlayer(g *guild, i *interaction) error {
+	user, target := i.Member.User.ID, i.userOption("player")
+	w, err := b.Watches.Watchlist(context.TODO(), g.player(user))
+	if err != nil {
+		return err
+	}
+
+	var content string
+	switch {
+	case target == user:
+		content = "You cannot watch yourself."
+	case contains(w.Players, target):
+		content = "<@" + target + "> is already on your watchlist."
+	case len(w.Players) >= maxWatched:
+		content = "Your watchlist is full, please remove a player first."
+	default:
+		err = b.Watches.Watch(context.TODO(), g.player(user), target)
+		if err != nil {
+			return err
+		}
+		content = "You will get a DM when <@" + target + "> goes online."
+	}
+	b.respondEphemeral(i, content)
+	return nil
+}
+
+func (b *Bot) unwatchPlayer(g *guild, i *interaction) error {
+	target := i.userOption("player")
+	removed, err := b.Watches.Unwatch(context.TODO(), g.player(i.Member.User.ID), target)
+	if err != nil {
+		return err
+	}
+
+	content := "<@" + target + "> is not on your watchlist."
+	if removed {
+		content = "<@" + target + "> has been removed from your watchlist."
+	}
+	b.respondEphemeral(i, content)
+	return nil
+}
+
+func (b *Bot) showWatchlist(g *guild, i *interaction) error {
+	w, err := b.Watches.Watchlist(context.TODO(), g.player(i.Member.User.ID))
+	if err != nil {
+		return err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		Color:       colorBlurple,
+		Title:       "Your watchlist",
+		Description: mentions(w.Players),
+	}
+	if len(w.Players) == 0 {
+		embed.Description = "You are not watching anyone. Use " + g.commandMention("watch add") + " to get a DM when a player goes online."
+	}
+	if time.Now().Before(w.MutedUntil) {
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Muted until:", Value: timestamp(w.MutedUntil, "f")}}
+	}
+
+	err = b.Session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		b.reportError(i.log, "Error responding to interaction", err)
+	}
+	return nil
+}
+
+func (b *Bot) muteWatchlist(g *guild, i *interaction) error {
+	d, err := time.ParseDuration(i.option("for"))
+	if err != nil {
+		return err
+	}
+
+	var until time.Time
+	content := "Watchlist notifications are on again."
+	if d > 0 {
+		until = time.Now().Add(d)
+		content = "Watchlist notifications are muted until " + timestamp(until, "f") + "."
+	}
+	err = b.Watches.MuteWatchlist(context.TODO(), g.player(i.Member.User.ID), until)
+	if err != nil {
+		return err
+	}
+	b.respondEphemeral(i, content)
+	return nil
+}
+
+// notifyWatchers sends a DM to everyone watching the player who just went
+// online, unless they muted their notifications.
+func (b *Bot) notifyWatchers(g *guild, p *Player, log *slog.Logger) {
+	watchers, err := b.Watches.Watchers(context.TODO(), g.Namespace, p.DiscordId)
+	if err != nil {
+		b.reportError(log, "Error reading watchers", err)
+		return
+	}
+
+	now := time.Now()
+	for _, w := range watchers {
+		if w.DiscordId == p.DiscordId || now.Before(w.MutedUntil) {
+			continue
+		}
+		channel, err := b.Session.UserChannelCreate(w.DiscordId)
+		if err == nil {
+			// DMs are not on a platform, so fields only shown there are left out
+			_, err = b.Session.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+				Content: "👀 A player on your watchlist is now online in <#" + g.Channels.Platforms[p.Platform] + ">.",
+				Embeds:  []*discordgo.MessageEmbed{b.onlineEmbed(p, "")},
+			})
+		}
+		if err != nil {
+			// Members may not accept DMs, which is not worth reporting
+			log.Warn("Could not send DM", "watcher_id", w.DiscordId, "err", err)
+		}
+	}
+}
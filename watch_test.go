@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestWatchlist(t *testing.T) {
+	t.Parallel()
+	b, f := newTestBot(t)
+	channel := f.channelID(b.Config.Platforms[1].Channel)
+	john := &discordgo.User{ID: "301", Username: "john"}
+	sadie := &discordgo.User{ID: "302", Username: "sadie"}
+	for _, user := range []*discordgo.User{testUser, john, sadie} {
+		f.submit(user, channel, "setup:"+user.ID, map[string]string{"rockstar_id": "", "bounty": "12", "footer": ""})
+	}
+
+	res := f.commandOptions(testUser, channel, "watch add", map[string]any{"player": testUser})
+	if res.Message.Content != "You cannot watch yourself." {
+		t.Errorf("watching yourself = %q", res.Message.Content)
+	}
+	res = f.command(testUser, channel, "watch list")
+	if !strings.HasPrefix(res.Message.Embeds[0].Description, "You are not watching anyone.") {
+		t.Errorf("empty watchlist = %q", res.Message.Embeds[0].Description)
+	}
+
+	f.commandOptions(testUser, channel, "watch add", map[string]any{"player": john})
+	f.commandOptions(sadie, channel, "watch add", map[string]any{"player": john})
+	res = f.commandOptions(testUser, channel, "watch add", map[string]any{"player": john})
+	if res.Message.Content != "<@"+john.ID+"> is already on your watchlist." {
+		t.Errorf("watching twice = %q", res.Message.Content)
+	}
+	res = f.command(testUser, channel, "watch list")
+	if res.Message.Embeds[0].Description != "<@"+john.ID+">" {
+		t.Errorf("watchlist = %q", res.Message.Embeds[0].Description)
+	}
+
+	f.command(john, channel, "online")
+	for _, user := range []*discordgo.User{testUser, sadie} {
+		messages := f.channelMessages(f.dmChannelID(user.ID))
+		if len(messages) != 1 || messages[0].Embeds[0].Title != john.Username+" is now online." {
+			t.Fatalf("DMs of %s = %+v, want online message of john", user.Username, messages)
+		}
+		if fields := eventFields(messages[0].Embeds[0]); fields["Bounty:"] == "" {
+			t.Errorf("DM fields = %v, want bounty", fields)
+		}
+	}
+	f.command(john, channel, "offline")
+
+	// Muted watchers are skipped until they unmute
+	res = f.commandOptions(testUser, channel, "watch mute", map[string]any{"for": "1h0m0s"})
+	if !strings.HasPrefix(res.Message.Content, "Watchlist notifications are muted until") {
+		t.Errorf("muting = %q", res.Message.Content)
+	}
+	f.command(john, channel, "online")
+	f.command(john, channel, "offline")
+	if messages := f.channelMessages(f.dmChannelID(testUser.ID)); len(messages) != 1 {
+		t.Errorf("muted watcher got %d DMs, want 1", len(messages))
+	}
+	if messages := f.channelMessages(f.dmChannelID(sadie.ID)); len(messages) != 2 {
+		t.Errorf("other watcher got %d DMs, want 2", len(messages))
+	}
+	f.commandOptions(testUser, channel, "watch mute", map[string]any{"for": "0s"})
+
+	res = f.commandOptions(testUser, channel, "watch remove", map[string]any{"player": john})
+	if res.Message.Content != "<@"+john.ID+"> has been removed from your watchlist." {
+		t.Errorf("removing = %q", res.Message.Content)
+	}
+	res = f.commandOptions(testUser, channel, "watch remove", map[string]any{"player": john})
+	if res.Message.Content != "<@"+john.ID+"> is not on your watchlist." {
+		t.Errorf("removing twice = %q", res.Message.Content)
+	}
+	f.command(john, channel, "online")
+	if messages := f.channelMessages(f.dmChannelID(testUser.ID)); len(messages) != 1 {
+		t.Errorf("former watcher got %d DMs, want 1", len(messages))
+	}
+}